@@ -4,29 +4,67 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/qingchencloud/cftunnel/internal/authproxy"
 	"github.com/qingchencloud/cftunnel/internal/cfapi"
 	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/daemon"
+	"github.com/qingchencloud/cftunnel/internal/passwd"
 	"github.com/spf13/cobra"
 )
 
 var addDomain string
 var addAuth string
+var addHtpasswd string
+var addOIDC config.OIDCAuth
+var addAccess config.AccessAuth
+var addAnonymousWS bool
+var addPublicPaths []string
+var addAccessLog bool
 
 func init() {
 	addCmd.Flags().StringVar(&addDomain, "domain", "", "完整域名 (如 webhook.example.com)")
 	addCmd.MarkFlagRequired("domain")
 	addCmd.Flags().StringVar(&addAuth, "auth", "", "启用密码保护 (格式: 用户名:密码)")
+	addCmd.Flags().StringVar(&addHtpasswd, "htpasswd", "", "使用 htpasswd 文件鉴权（bcrypt/SHA/apr1，可与 --auth 同时使用）")
+	addCmd.Flags().StringVar(&addOIDC.Issuer, "oidc-issuer", "", "使用 OIDC 登录，身份提供商 Issuer URL")
+	addCmd.Flags().StringVar(&addOIDC.ClientID, "oidc-client-id", "", "OIDC Client ID")
+	addCmd.Flags().StringVar(&addOIDC.ClientSecret, "oidc-client-secret", "", "OIDC Client Secret（公共客户端可省略）")
+	addCmd.Flags().StringSliceVar(&addOIDC.AllowedEmails, "oidc-allowed-email", nil, "允许登录的邮箱（可重复）")
+	addCmd.Flags().StringSliceVar(&addOIDC.AllowedDomains, "oidc-allowed-domain", nil, "允许登录的邮箱域名（可重复）")
+	addCmd.Flags().BoolVar(&addAnonymousWS, "allow-anonymous-ws", false, "允许未登录的 WebSocket 连接（默认 WebSocket 同样需要鉴权）")
+	addCmd.Flags().StringSliceVar(&addPublicPaths, "public-path", nil, "无需鉴权的路径，前缀或通配符（可重复，如 /healthz、/webhook/*）")
+	addCmd.Flags().BoolVar(&addAccessLog, "access-log", false, "记录访问日志（启用鉴权的路由默认记录）")
+	addCmd.Flags().StringVar(&addAccess.TeamDomain, "access-team", "", "校验 Cloudflare Access JWT，团队域名 (如 myteam.cloudflareaccess.com)")
+	addCmd.Flags().StringVar(&addAccess.AUD, "access-aud", "", "Cloudflare Access 应用的 AUD 标签")
 	rootCmd.AddCommand(addCmd)
 }
 
-// pushIngress 推送当前所有路由的 ingress 配置到远端
+// pushIngress 推送 ingress；守护进程运行中时沿用其鉴权代理端口
 func pushIngress(client *cfapi.Client, ctx context.Context, cfg *config.Config) error {
+	return pushIngressPorts(client, ctx, cfg, daemon.RunningProxyPorts(cfg.Tunnel.ID))
+}
+
+// pushIngressPorts 推送 ingress，需要代理的路由指向 ports 中的代理端口；
+// 没有可用代理的受保护路由不推送，绝不直连源站，启动或重启守护进程后生效
+func pushIngressPorts(client *cfapi.Client, ctx context.Context, cfg *config.Config, ports map[string]int) error {
 	var rules []cfapi.IngressRule
 	for _, r := range cfg.Routes {
-		rules = append(rules, cfapi.IngressRule{Hostname: r.Hostname, Service: r.Service})
+		service := r.Service
+		if needsProxy(cfg, r) {
+			port, ok := ports[r.Name]
+			if !ok {
+				if daemon.Running() {
+					fmt.Printf("路由 %s 需要经鉴权代理转发，暂不接入，执行 cftunnel down && cftunnel up 后生效\n", r.Name)
+				}
+				continue
+			}
+			service = "http://localhost:" + strconv.Itoa(port)
+		}
+		rules = append(rules, cfapi.IngressRule{Hostname: r.Hostname, Service: service})
 	}
 	return client.PushIngressConfig(ctx, cfg.Tunnel.ID, rules)
 }
@@ -45,6 +83,25 @@ func findZoneForDomain(client *cfapi.Client, ctx context.Context, domain string)
 	return nil, fmt.Errorf("未找到域名 %s 对应的 Zone，请确认域名已添加到 Cloudflare", domain)
 }
 
+// upsertCNAME 创建或更新指向隧道的 CNAME 记录，返回记录 ID
+func upsertCNAME(client *cfapi.Client, ctx context.Context, zoneID, hostname, target string) (string, error) {
+	existingRecordID, err := client.FindDNSRecord(ctx, zoneID, hostname)
+	if err != nil {
+		return "", err
+	}
+	if existingRecordID != "" {
+		// 记录已存在,更新
+		fmt.Printf("DNS 记录已存在,正在更新 %s → %s\n", hostname, target)
+		if err := client.UpdateCNAME(ctx, zoneID, existingRecordID, hostname, target); err != nil {
+			return "", err
+		}
+		return existingRecordID, nil
+	}
+	// 记录不存在,创建
+	fmt.Printf("正在创建 DNS 记录 %s → %s\n", hostname, target)
+	return client.CreateCNAME(ctx, zoneID, hostname, target)
+}
+
 var addCmd = &cobra.Command{
 	Use:   "add <名称> <端口>",
 	Short: "添加路由（自动创建 CNAME + 更新 ingress）",
@@ -64,6 +121,20 @@ var addCmd = &cobra.Command{
 			return fmt.Errorf("路由 %s 已存在", name)
 		}
 
+		// 先校验鉴权参数，避免创建 DNS 后才报错
+		auth, err := buildAddAuth()
+		if err != nil {
+			return err
+		}
+		var access *config.AccessAuth
+		if addAccess.TeamDomain != "" || addAccess.AUD != "" {
+			if addAccess.TeamDomain == "" || addAccess.AUD == "" {
+				return fmt.Errorf("Access 校验需要同时指定 --access-team 和 --access-aud")
+			}
+			a := addAccess
+			access = &a
+		}
+
 		client := cfapi.New(cfg.Auth.APIToken, cfg.Auth.AccountID)
 		ctx := context.Background()
 
@@ -73,30 +144,13 @@ var addCmd = &cobra.Command{
 			return err
 		}
 
-		// 检查 DNS 记录是否已存在
+		// 创建 DNS 记录（已存在则更新）
 		target := cfg.Tunnel.ID + ".cfargotunnel.com"
-		existingRecordID, err := client.FindDNSRecord(ctx, zone.ID, addDomain)
+		recordID, err := upsertCNAME(client, ctx, zone.ID, addDomain, target)
 		if err != nil {
 			return err
 		}
 
-		var recordID string
-		if existingRecordID != "" {
-			// 记录已存在,更新
-			fmt.Printf("DNS 记录已存在,正在更新 %s → %s\n", addDomain, target)
-			if err := client.UpdateCNAME(ctx, zone.ID, existingRecordID, addDomain, target); err != nil {
-				return err
-			}
-			recordID = existingRecordID
-		} else {
-			// 记录不存在,创建
-			fmt.Printf("正在创建 DNS 记录 %s → %s\n", addDomain, target)
-			recordID, err = client.CreateCNAME(ctx, zone.ID, addDomain, target)
-			if err != nil {
-				return err
-			}
-		}
-
 		// 构建路由配置
 		route := config.RouteConfig{
 			Name:        name,
@@ -104,25 +158,27 @@ var addCmd = &cobra.Command{
 			Service:     service,
 			ZoneID:      zone.ID,
 			DNSRecordID: recordID,
+			Auth:        auth,
+			Access:      access,
+			AccessLog:   addAccessLog,
 		}
-
-		// 如果指定了 --auth，填充鉴权配置
-		if addAuth != "" {
-			user, pass, err := parseAuth(addAuth)
-			if err != nil {
-				return err
-			}
-			route.Auth = &config.AuthProxy{
-				Username:   user,
-				Password:   pass,
-				SigningKey:  hex.EncodeToString(authproxy.RandomKey()),
-			}
-			fmt.Printf("已启用密码保护: %s\n", addDomain)
+		if auth != nil {
+			fmt.Printf("已启用访问保护: %s\n", addDomain)
+		}
+		if access != nil {
+			fmt.Printf("已启用 Cloudflare Access 校验: %s\n", addDomain)
 		}
 
-		// 保存路由
-		cfg.Routes = append(cfg.Routes, route)
-		if err := cfg.Save(); err != nil {
+		// 保存路由（锁内重新加载，避免覆盖其他进程的并发修改）
+		err = config.Update(func(latest *config.Config) error {
+			if latest.FindRoute(name) != nil {
+				return fmt.Errorf("路由 %s 已存在", name)
+			}
+			latest.Routes = append(latest.Routes, route)
+			cfg = latest
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 
@@ -136,3 +192,60 @@ var addCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// buildAddAuth 根据 --auth / --htpasswd / --oidc-* 参数生成鉴权配置，均未指定时返回 nil
+func buildAddAuth() (*config.AuthProxy, error) {
+	useOIDC := addOIDC.Issuer != "" || addOIDC.ClientID != ""
+	if addAuth == "" && addHtpasswd == "" && !useOIDC {
+		if len(addPublicPaths) > 0 {
+			return nil, fmt.Errorf("--public-path 需与 --auth / --htpasswd / --oidc-* 同时使用")
+		}
+		return nil, nil
+	}
+	if err := validatePublicPaths(addPublicPaths); err != nil {
+		return nil, err
+	}
+	auth := &config.AuthProxy{
+		SigningKey:  hex.EncodeToString(authproxy.RandomKey()),
+		AnonymousWS: addAnonymousWS,
+		PublicPaths: addPublicPaths,
+	}
+
+	if useOIDC {
+		if addAuth != "" || addHtpasswd != "" {
+			return nil, fmt.Errorf("--oidc-* 不能与 --auth / --htpasswd 同时使用")
+		}
+		if addOIDC.Issuer == "" || addOIDC.ClientID == "" {
+			return nil, fmt.Errorf("OIDC 需要同时指定 --oidc-issuer 和 --oidc-client-id")
+		}
+		if len(addOIDC.AllowedEmails) == 0 && len(addOIDC.AllowedDomains) == 0 {
+			return nil, fmt.Errorf("请通过 --oidc-allowed-email 或 --oidc-allowed-domain 限定允许登录的用户")
+		}
+		oidc := addOIDC
+		auth.OIDC = &oidc
+		return auth, nil
+	}
+
+	if addAuth != "" {
+		user, pass, err := parseAuth(addAuth)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := passwd.Hash(pass)
+		if err != nil {
+			return nil, err
+		}
+		auth.Users = []config.AuthUser{{Username: user, Password: hash}}
+	}
+	if addHtpasswd != "" {
+		path, err := filepath.Abs(addHtpasswd)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := passwd.ParseHtpasswd(path); err != nil {
+			return nil, fmt.Errorf("读取 htpasswd 文件失败: %w", err)
+		}
+		auth.HtpasswdFile = path
+	}
+	return auth, nil
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/cfapi"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/manifest"
+	"github.com/qingchencloud/cftunnel/internal/passwd"
+	"github.com/qingchencloud/cftunnel/internal/relay"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyFile  string
+	applyForce bool
+)
+
+func init() {
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "清单文件路径 (YAML)")
+	applyCmd.MarkFlagRequired("file")
+	applyCmd.Flags().BoolVar(&applyForce, "force", false, "跳过确认")
+	rootCmd.AddCommand(applyCmd)
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply -f <清单>",
+	Short: "按清单声明式同步隧道、路由、鉴权和中继规则",
+	Long: `按 YAML 清单同步配置：创建/更新/删除 DNS 记录，推送 ingress，更新中继规则。
+清单中未列出的路由会被删除；省略 relay 段时不改动中继配置。
+清单支持 ${VAR} 引用环境变量，避免将密码和 token 明文提交到仓库。
+
+示例清单:
+  tunnel: my-tunnel
+  routes:
+    - name: app
+      hostname: app.example.com
+      port: 3000
+      auth:
+        username: admin
+        password: ${APP_PASSWORD}
+  relay:
+    server: 1.2.3.4:7000
+    token: ${RELAY_TOKEN}
+    rules:
+      - name: ssh
+        proto: tcp
+        local_port: 22
+        remote_port: 6022`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 等待确认时不持有配置锁，避免阻塞其他命令和守护进程读取配置
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		plan, err := loadPlan(cfg, applyFile)
+		if err != nil {
+			return err
+		}
+		plan.Print(os.Stdout)
+		if plan.Empty() {
+			return nil
+		}
+		if !applyForce {
+			fmt.Print("\n确认应用以上变更？(y/N): ")
+			reader := bufio.NewReader(os.Stdin)
+			input, _ := reader.ReadString('\n')
+			if strings.TrimSpace(strings.ToLower(input)) != "y" {
+				fmt.Println("已取消")
+				return nil
+			}
+		}
+		var confirmed bytes.Buffer
+		plan.Print(&confirmed)
+
+		// 持锁重新计算，与确认的计划一致才应用
+		return config.Update(func(cfg *config.Config) error {
+			plan, err := loadPlan(cfg, applyFile)
+			if err != nil {
+				return err
+			}
+			var current bytes.Buffer
+			plan.Print(&current)
+			if current.String() != confirmed.String() {
+				return fmt.Errorf("确认期间配置或清单已变更，请重新执行 cftunnel apply")
+			}
+			return applyPlan(cfg, plan)
+		})
+	},
+}
+
+// applyPlan 执行变更计划：远端操作逐项完成后再更新本地配置
+func applyPlan(cfg *config.Config, plan *manifest.Plan) error {
+	client := cfapi.New(cfg.Auth.APIToken, cfg.Auth.AccountID)
+	ctx := context.Background()
+
+	if plan.CreateTunnel != "" {
+		fmt.Printf("正在创建隧道 %s...\n", plan.CreateTunnel)
+		tunnel, err := client.CreateTunnel(ctx, plan.CreateTunnel)
+		if err != nil {
+			return err
+		}
+		token, err := client.GetTunnelToken(ctx, tunnel.ID)
+		if err != nil {
+			return err
+		}
+		cfg.Tunnel = config.TunnelConfig{ID: tunnel.ID, Name: tunnel.Name, Token: token}
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+	}
+	target := cfg.Tunnel.ID + ".cfargotunnel.com"
+
+	for _, c := range plan.Routes {
+		switch c.Action {
+		case manifest.Delete:
+			deleteRouteDNS(client, ctx, c.Current)
+			cfg.RemoveRoute(c.Name)
+		case manifest.Create, manifest.Update:
+			route := config.RouteConfig{
+				Name:      c.Desired.Name,
+				Hostname:  c.Desired.Hostname,
+				Service:   c.Desired.Service,
+				Access:    c.Desired.Access,
+				IPFilter:  c.Desired.IPFilter,
+				AccessLog: c.Desired.AccessLog,
+			}
+			if c.Current != nil {
+				route.ZoneID, route.DNSRecordID = c.Current.ZoneID, c.Current.DNSRecordID
+			}
+			if c.HostnameChanged() {
+				zone, err := findZoneForDomain(client, ctx, route.Hostname)
+				if err != nil {
+					return err
+				}
+				recordID, err := upsertCNAME(client, ctx, zone.ID, route.Hostname, target)
+				if err != nil {
+					return err
+				}
+				if c.Current != nil {
+					deleteRouteDNS(client, ctx, c.Current)
+				}
+				route.ZoneID, route.DNSRecordID = zone.ID, recordID
+			}
+			auth, err := desiredAuth(c)
+			if err != nil {
+				return err
+			}
+			route.Auth = auth
+			if cur := cfg.FindRoute(route.Name); cur != nil {
+				*cur = route
+			} else {
+				cfg.Routes = append(cfg.Routes, route)
+			}
+		}
+		// 每完成一条即保存，中途失败时本地配置与远端保持一致
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+	}
+	sortRoutes(cfg, plan)
+
+	if plan.Relay != nil {
+		cfg.Relay = *plan.Relay
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	if len(plan.Routes) > 0 || len(plan.Ingress) > 0 {
+		fmt.Println("正在同步 ingress 配置...")
+		if err := pushIngress(client, ctx, cfg); err != nil {
+			return fmt.Errorf("推送 ingress 失败: %w", err)
+		}
+	}
+	if plan.Relay != nil && relay.Running() {
+		fmt.Println("中继配置已更新，执行 cftunnel relay down && cftunnel relay up 生效")
+	}
+	fmt.Println("✔ 清单已应用")
+	return nil
+}
+
+// desiredAuth 生成路由的鉴权配置，已有签名密钥和未变更的密码哈希时保留，避免已登录用户失效
+func desiredAuth(c manifest.RouteChange) (*config.AuthProxy, error) {
+	want := c.Desired.Auth
+	if want == nil {
+		return nil, nil
+	}
+	auth := &config.AuthProxy{
+		HtpasswdFile: want.HtpasswdFile,
+		OIDC:         want.OIDC,
+		AnonymousWS:  want.AnonymousWS,
+		PublicPaths:  want.PublicPaths,
+		PortalDomain: want.PortalDomain,
+		SSO:          want.SSO,
+		Headers:      want.Headers,
+		LoginPage:    want.LoginPage,
+		CookieTTL:    want.CookieTTL,
+	}
+	var cur *config.AuthProxy
+	if c.Current != nil {
+		cur = c.Current.Auth
+	}
+	for _, u := range want.Users {
+		var existing *config.AuthUser
+		if cur != nil {
+			existing = cur.FindUser(u.Username)
+		}
+		if existing != nil && passwd.Verify(existing.Password, u.Password) {
+			user := *existing
+			user.Groups = u.Groups
+			auth.Users = append(auth.Users, user)
+			continue
+		}
+		h, err := passwd.Hash(u.Password)
+		if err != nil {
+			return nil, err
+		}
+		user := config.AuthUser{Username: u.Username, Password: h, Groups: u.Groups}
+		if existing != nil {
+			user.TOTPSecret = existing.TOTPSecret // 两步验证通过 cftunnel auth totp 管理，修改密码时保留
+		}
+		auth.Users = append(auth.Users, user)
+	}
+	// API Key 通过 cftunnel auth key 管理，清单不涉及，保留现有密钥
+	if cur != nil {
+		auth.APIKeys = cur.APIKeys
+	}
+	if cur != nil && cur.SigningKey != "" {
+		auth.SigningKey = cur.SigningKey
+	} else {
+		auth.SigningKey = hex.EncodeToString(authproxy.RandomKey())
+	}
+	return auth, nil
+}
+
+// deleteRouteDNS 删除路由对应的 DNS 记录，失败仅警告
+func deleteRouteDNS(client *cfapi.Client, ctx context.Context, r *config.RouteConfig) {
+	if r.DNSRecordID == "" || r.ZoneID == "" {
+		return
+	}
+	fmt.Printf("正在删除 DNS 记录 %s...\n", r.Hostname)
+	if err := client.DeleteDNSRecord(ctx, r.ZoneID, r.DNSRecordID); err != nil {
+		fmt.Printf("警告: 删除 DNS 记录失败: %v\n", err)
+	}
+}
+
+// sortRoutes 按清单顺序排列路由（ingress 规则按顺序匹配）
+func sortRoutes(cfg *config.Config, plan *manifest.Plan) {
+	order := plan.Order()
+	routes := make([]config.RouteConfig, 0, len(cfg.Routes))
+	for _, name := range order {
+		if r := cfg.FindRoute(name); r != nil {
+			routes = append(routes, *r)
+		}
+	}
+	cfg.Routes = routes
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "管理路由鉴权（用户、API Key、htpasswd、Cloudflare Access、IP 过滤）",
+	Long:  "管理 Cloud 路由鉴权代理的访问凭据，无需重建路由。\n变更保存到配置文件，隧道运行中时需重启（cftunnel down && cftunnel up）生效；htpasswd 文件修改后自动重新加载。",
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+}
+
+// findAuthRoute 查找路由，不存在时返回错误
+func findAuthRoute(cfg *config.Config, name string) (*config.RouteConfig, error) {
+	route := cfg.FindRoute(name)
+	if route == nil {
+		return nil, fmt.Errorf("路由 %s 不存在", name)
+	}
+	return route, nil
+}
+
+// printAuthReloadHint 隧道运行中时提示重启生效
+func printAuthReloadHint() {
+	if daemon.Running() {
+		fmt.Println("隧道运行中，执行 cftunnel down && cftunnel up 使变更生效")
+	}
+}
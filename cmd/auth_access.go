@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authAccessTeam string
+	authAccessAUD  string
+	authAccessOff  bool
+)
+
+func init() {
+	authAccessCmd.Flags().StringVar(&authAccessTeam, "team", "", "团队域名 (如 myteam.cloudflareaccess.com)")
+	authAccessCmd.Flags().StringVar(&authAccessAUD, "aud", "", "Access 应用的 AUD 标签")
+	authAccessCmd.Flags().BoolVar(&authAccessOff, "off", false, "关闭 Access 校验")
+	authCmd.AddCommand(authAccessCmd)
+}
+
+var authAccessCmd = &cobra.Command{
+	Use:   "access <路由> --team <团队域名> --aud <AUD>",
+	Short: "校验 Cloudflare Access JWT，拒绝绕过 Access 直连源站的请求",
+	Long: `为已接入 Cloudflare Access 的路由开启源站校验：请求必须携带有效的 Cf-Access-Jwt-Assertion，
+签名通过团队公钥 (https://<团队域名>/cdn-cgi/access/certs，自动缓存) 校验，且 aud 与应用 AUD 标签一致。
+可与密码 / OIDC 登录叠加使用。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		if !authAccessOff && (authAccessTeam == "" || authAccessAUD == "") {
+			return fmt.Errorf("请指定 --team 和 --aud，或使用 --off 关闭")
+		}
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if authAccessOff {
+				if route.Access == nil {
+					return fmt.Errorf("路由 %s 未启用 Access 校验", routeName)
+				}
+				route.Access = nil
+				return nil
+			}
+			route.Access = &config.AccessAuth{TeamDomain: authAccessTeam, AUD: authAccessAUD}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if authAccessOff {
+			fmt.Printf("✔ 已关闭 Cloudflare Access 校验: %s\n", routeName)
+		} else {
+			fmt.Printf("✔ 已启用 Cloudflare Access 校验: %s (%s)\n", routeName, authAccessTeam)
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/textproto"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authHeaders        config.IdentityHeaders
+	authHeadersGenKey  bool
+	authHeadersNoSign  bool
+	authHeadersDefault bool
+)
+
+func init() {
+	authHeadersCmd.Flags().StringVar(&authHeaders.User, "user", "", "用户名头（默认 "+authproxy.DefaultUserHeader+"）")
+	authHeadersCmd.Flags().StringVar(&authHeaders.Email, "email", "", "邮箱头（默认 "+authproxy.DefaultEmailHeader+"）")
+	authHeadersCmd.Flags().StringVar(&authHeaders.Groups, "groups", "", "组头（默认 "+authproxy.DefaultGroupsHeader+"，多个组以逗号分隔）")
+	authHeadersCmd.Flags().StringVar(&authHeaders.Secret, "secret", "", "签名共享密钥，设置后附加 "+authproxy.SignatureHeader)
+	authHeadersCmd.Flags().BoolVar(&authHeadersGenKey, "generate-secret", false, "生成随机签名密钥")
+	authHeadersCmd.Flags().BoolVar(&authHeadersNoSign, "no-sign", false, "关闭签名")
+	authHeadersCmd.Flags().BoolVar(&authHeadersDefault, "reset", false, "恢复默认头名称并关闭签名")
+	authCmd.AddCommand(authHeadersCmd)
+}
+
+var authHeadersCmd = &cobra.Command{
+	Use:   "headers <路由> [--user 头] [--email 头] [--groups 头] [--secret 密钥 | --generate-secret]",
+	Short: "配置转发给后端的身份头（不指定参数时显示当前配置）",
+	Long: `鉴权代理总会删除客户端自带的身份头，再按已验证的登录身份写入，后端（如 Grafana 的 auth proxy 模式）可直接信任：
+  X-Forwarded-User    用户名（API Key 为 key:<ID>，分享链接为 share:<链接ID>）
+  X-Forwarded-Email   邮箱（OIDC / Access 登录或用户名为邮箱时）
+  X-Forwarded-Groups  组，逗号分隔（cftunnel auth user add --group，OIDC 取 ID Token 的 groups）
+设置签名密钥后附加 X-Forwarded-Auth-Signature: t=<Unix 秒>,v1=<hex>，
+其中 v1 = HMAC-SHA256(密钥, "<t>\n<用户名>\n<邮箱>\n<组>")，后端可据此确认请求来自代理。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		h := authHeaders
+		if cmd.Flags().NFlag() == 0 {
+			return showIdentityHeaders(routeName)
+		}
+		if authHeadersDefault && cmd.Flags().NFlag() > 1 {
+			return fmt.Errorf("--reset 不能与其他参数同时使用")
+		}
+		if authHeadersGenKey && h.Secret != "" || authHeadersNoSign && (authHeadersGenKey || h.Secret != "") {
+			return fmt.Errorf("--secret、--generate-secret 和 --no-sign 只能指定其一")
+		}
+		if authHeadersGenKey {
+			h.Secret = hex.EncodeToString(authproxy.RandomKey())
+		}
+		for _, name := range []*string{&h.User, &h.Email, &h.Groups} {
+			if *name != "" {
+				if err := authproxy.ValidateHeaderName(*name); err != nil {
+					return err
+				}
+				*name = textproto.CanonicalMIMEHeaderKey(*name)
+			}
+		}
+
+		var result *config.IdentityHeaders
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if route.Auth == nil {
+				return fmt.Errorf("路由 %s 未启用鉴权", routeName)
+			}
+			if authHeadersDefault {
+				route.Auth.Headers = nil
+				return nil
+			}
+			cur := config.IdentityHeaders{}
+			if route.Auth.Headers != nil {
+				cur = *route.Auth.Headers
+			}
+			if h.User != "" {
+				cur.User = h.User
+			}
+			if h.Email != "" {
+				cur.Email = h.Email
+			}
+			if h.Groups != "" {
+				cur.Groups = h.Groups
+			}
+			if h.Secret != "" {
+				cur.Secret = h.Secret
+			}
+			if authHeadersNoSign {
+				cur.Secret = ""
+			}
+			route.Auth.Headers = &cur
+			result = &cur
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if authHeadersDefault {
+			fmt.Printf("✔ 已恢复默认身份头: %s\n", routeName)
+		} else {
+			fmt.Printf("✔ 已更新身份头: %s\n", routeName)
+			printIdentityHeaders(result)
+			if authHeadersGenKey {
+				fmt.Printf("  签名密钥: %s（请配置到后端用于校验）\n", h.Secret)
+			}
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
+
+// showIdentityHeaders 显示路由当前的身份头配置
+func showIdentityHeaders(routeName string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	route, err := findAuthRoute(cfg, routeName)
+	if err != nil {
+		return err
+	}
+	if route.Auth == nil {
+		fmt.Printf("路由 %s 未启用鉴权\n", routeName)
+		return nil
+	}
+	h := route.Auth.Headers
+	if h == nil {
+		h = &config.IdentityHeaders{}
+	}
+	printIdentityHeaders(h)
+	return nil
+}
+
+func printIdentityHeaders(h *config.IdentityHeaders) {
+	orDefault := func(v, def string) string {
+		if v == "" {
+			return def
+		}
+		return v
+	}
+	fmt.Printf("  用户名: %s\n", orDefault(h.User, authproxy.DefaultUserHeader))
+	fmt.Printf("  邮箱:   %s\n", orDefault(h.Email, authproxy.DefaultEmailHeader))
+	fmt.Printf("  组:     %s\n", orDefault(h.Groups, authproxy.DefaultGroupsHeader))
+	if h.Secret != "" {
+		fmt.Printf("  签名:   %s（已启用）\n", authproxy.SignatureHeader)
+	} else {
+		fmt.Println("  签名:   未启用")
+	}
+}
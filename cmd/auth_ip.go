@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authIPFilter config.IPFilter
+	authIPOff    bool
+)
+
+func init() {
+	authIPCmd.Flags().StringSliceVar(&authIPFilter.Allow, "allow", nil, "仅允许的 CIDR 或 IP（可重复）")
+	authIPCmd.Flags().StringSliceVar(&authIPFilter.Deny, "deny", nil, "拒绝的 CIDR 或 IP（可重复）")
+	authIPCmd.Flags().StringSliceVar(&authIPFilter.AllowCountries, "allow-country", nil, "仅允许的国家代码（CF-IPCountry，可重复）")
+	authIPCmd.Flags().StringSliceVar(&authIPFilter.DenyCountries, "deny-country", nil, "拒绝的国家代码（可重复，如 T1 表示 Tor）")
+	authIPCmd.Flags().BoolVar(&authIPOff, "off", false, "关闭 IP 过滤")
+	authCmd.AddCommand(authIPCmd)
+}
+
+var authIPCmd = &cobra.Command{
+	Use:   "ip <路由> [--allow CIDR] [--deny CIDR] [--allow-country CC] [--deny-country CC]",
+	Short: "按客户端 IP / 国家限制访问（不指定参数时显示当前规则）",
+	Long: `按 cloudflared 转发的 CF-Connecting-IP 和 CF-IPCountry 过滤请求，未通过返回 403。
+deny 优先于 allow；allow 非空时仅放行匹配项，国家未知（未开启 IP 地理位置）的请求不满足国家白名单。
+每次设置整体替换该路由的规则，可独立使用，无需启用密码保护。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		f := authIPFilter
+		empty := len(f.Allow) == 0 && len(f.Deny) == 0 && len(f.AllowCountries) == 0 && len(f.DenyCountries) == 0
+		if empty && !authIPOff {
+			return showIPFilter(routeName)
+		}
+		if !empty && authIPOff {
+			return fmt.Errorf("--off 不能与其他参数同时使用")
+		}
+		for _, s := range append(append([]string{}, f.Allow...), f.Deny...) {
+			if _, err := authproxy.ParsePrefix(s); err != nil {
+				return err
+			}
+		}
+		for i, c := range f.AllowCountries {
+			f.AllowCountries[i] = strings.ToUpper(c)
+		}
+		for i, c := range f.DenyCountries {
+			f.DenyCountries[i] = strings.ToUpper(c)
+		}
+
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if authIPOff {
+				if route.IPFilter == nil {
+					return fmt.Errorf("路由 %s 未启用 IP 过滤", routeName)
+				}
+				route.IPFilter = nil
+				return nil
+			}
+			route.IPFilter = &f
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if authIPOff {
+			fmt.Printf("✔ 已关闭 IP 过滤: %s\n", routeName)
+		} else {
+			fmt.Printf("✔ 已更新 IP 过滤: %s\n", routeName)
+			printIPFilter(&f)
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
+
+// showIPFilter 显示路由当前的 IP 过滤规则
+func showIPFilter(routeName string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	route, err := findAuthRoute(cfg, routeName)
+	if err != nil {
+		return err
+	}
+	if route.IPFilter == nil {
+		fmt.Printf("路由 %s 未启用 IP 过滤\n", routeName)
+		return nil
+	}
+	printIPFilter(route.IPFilter)
+	return nil
+}
+
+func printIPFilter(f *config.IPFilter) {
+	rows := []struct {
+		label  string
+		values []string
+	}{
+		{"允许 IP", f.Allow},
+		{"拒绝 IP", f.Deny},
+		{"允许国家", f.AllowCountries},
+		{"拒绝国家", f.DenyCountries},
+	}
+	for _, r := range rows {
+		if len(r.values) > 0 {
+			fmt.Printf("  %s: %s\n", r.label, strings.Join(r.values, ", "))
+		}
+	}
+}
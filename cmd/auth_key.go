@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var authKeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "管理路由 API Key（Authorization: Bearer，供脚本和 CI 使用）",
+}
+
+func init() {
+	authCmd.AddCommand(authKeyCmd)
+}
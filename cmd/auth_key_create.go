@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var authKeyCreateName string
+
+func init() {
+	authKeyCreateCmd.Flags().StringVar(&authKeyCreateName, "name", "", "密钥名称（便于识别和吊销）")
+	authKeyCmd.AddCommand(authKeyCreateCmd)
+}
+
+var authKeyCreateCmd = &cobra.Command{
+	Use:   "create <路由>",
+	Short: "创建 API Key（密钥仅显示一次，配置中只保存摘要）",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		id, key, hash := authproxy.NewAPIKey()
+
+		var hostname string
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if route.Auth == nil {
+				route.Auth = &config.AuthProxy{SigningKey: hex.EncodeToString(authproxy.RandomKey())}
+				fmt.Printf("已启用鉴权: %s（浏览器登录需另外添加用户: cftunnel auth user add）\n", route.Hostname)
+			}
+			if authKeyCreateName != "" && route.Auth.FindAPIKey(authKeyCreateName) != nil {
+				return fmt.Errorf("路由 %s 中已存在名为 %s 的 API Key", routeName, authKeyCreateName)
+			}
+			route.Auth.APIKeys = append(route.Auth.APIKeys, config.APIKey{
+				ID:      id,
+				Name:    authKeyCreateName,
+				Hash:    hash,
+				Created: time.Now().Format(time.RFC3339),
+			})
+			hostname = route.Hostname
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ API Key 已创建: %s (%s)\n", id, routeName)
+		fmt.Printf("  %s\n", key)
+		fmt.Println("请妥善保存，该密钥不会再次显示。使用方式:")
+		fmt.Printf("  curl -H \"Authorization: Bearer %s\" https://%s/\n", key, hostname)
+		printAuthReloadHint()
+		return nil
+	},
+}
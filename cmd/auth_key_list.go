@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authKeyCmd.AddCommand(authKeyListCmd)
+}
+
+var authKeyListCmd = &cobra.Command{
+	Use:   "list <路由>",
+	Short: "列出路由的 API Key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		route, err := findAuthRoute(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		if route.Auth == nil || len(route.Auth.APIKeys) == 0 {
+			fmt.Printf("路由 %s 暂无 API Key\n", args[0])
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\t名称\t创建时间")
+		fmt.Fprintln(w, "--\t----\t--------")
+		for _, k := range route.Auth.APIKeys {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", k.ID, k.Name, k.Created)
+		}
+		w.Flush()
+		return nil
+	},
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authKeyCmd.AddCommand(authKeyRevokeCmd)
+}
+
+var authKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke <路由> <ID或名称>",
+	Short: "吊销 API Key",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName, ref := args[0], args[1]
+		var id string
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			var k *config.APIKey
+			if route.Auth != nil {
+				k = route.Auth.FindAPIKey(ref)
+			}
+			if k == nil {
+				return fmt.Errorf("路由 %s 中不存在 API Key %s", routeName, ref)
+			}
+			id = k.ID
+			route.Auth.RemoveAPIKey(id)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ API Key 已吊销: %s (%s)\n", id, routeName)
+		printAuthReloadHint()
+		return nil
+	},
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginPageTemplate string
+	loginPageTitle    string
+	loginPageMessage  string
+	loginPageReset    bool
+)
+
+func init() {
+	authLoginPageCmd.Flags().StringVar(&loginPageTemplate, "template", "", "自定义模板文件（html/template）")
+	authLoginPageCmd.Flags().StringVar(&loginPageTitle, "title", "", "页面标题")
+	authLoginPageCmd.Flags().StringVar(&loginPageMessage, "message", "", "标题下方的说明文字")
+	authLoginPageCmd.Flags().BoolVar(&loginPageReset, "reset", false, "恢复内置登录页")
+	authCmd.AddCommand(authLoginPageCmd)
+}
+
+var authLoginPageCmd = &cobra.Command{
+	Use:   "login-page <路由> [--template 文件] [--title 标题] [--message 说明]",
+	Short: "定制登录页（不指定参数时显示当前配置）",
+	Long: `定制鉴权代理的登录页。内置页面按浏览器 Accept-Language 显示中文或英文，标题和说明文字原样显示。
+自定义模板使用 Go html/template 语法，可用字段：
+  .Lang     页面语言（zh-CN / en）      .Route    路由名称
+  .Title    标题                          .Message  说明文字
+  .Error    登录失败原因（可能为空）      .TOTP     是否需要显示验证码输入框
+  .Action   表单提交地址                  .CSRF     CSRF 令牌
+  .T        当前语言的界面文字（.T.Username、.T.Password、.T.TOTP、.T.Submit）
+表单须以 POST 提交到 .Action，字段为 username、password、totp，并包含隐藏字段 csrf（值为 .CSRF）。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		if cmd.Flags().NFlag() == 0 {
+			return showLoginPage(routeName)
+		}
+		if loginPageReset && cmd.Flags().NFlag() > 1 {
+			return fmt.Errorf("--reset 不能与其他参数同时使用")
+		}
+		tmpl := loginPageTemplate
+		if tmpl != "" {
+			path, err := filepath.Abs(tmpl)
+			if err != nil {
+				return err
+			}
+			if err := authproxy.ValidateLoginTemplate(path); err != nil {
+				return err
+			}
+			tmpl = path
+		}
+
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if route.Auth == nil {
+				return fmt.Errorf("路由 %s 未启用鉴权", routeName)
+			}
+			if loginPageReset {
+				route.Auth.LoginPage = nil
+				return nil
+			}
+			cur := config.LoginPage{}
+			if route.Auth.LoginPage != nil {
+				cur = *route.Auth.LoginPage
+			}
+			if cmd.Flags().Changed("template") {
+				cur.Template = tmpl
+			}
+			if cmd.Flags().Changed("title") {
+				cur.Title = loginPageTitle
+			}
+			if cmd.Flags().Changed("message") {
+				cur.Message = loginPageMessage
+			}
+			if cur == (config.LoginPage{}) {
+				route.Auth.LoginPage = nil
+			} else {
+				route.Auth.LoginPage = &cur
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if loginPageReset {
+			fmt.Printf("✔ 已恢复内置登录页: %s\n", routeName)
+		} else {
+			fmt.Printf("✔ 已更新登录页: %s\n", routeName)
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
+
+// showLoginPage 显示路由当前的登录页配置
+func showLoginPage(routeName string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	route, err := findAuthRoute(cfg, routeName)
+	if err != nil {
+		return err
+	}
+	if route.Auth == nil {
+		fmt.Printf("路由 %s 未启用鉴权\n", routeName)
+		return nil
+	}
+	lp := route.Auth.LoginPage
+	if lp == nil {
+		lp = &config.LoginPage{}
+	}
+	orDefault := func(v, def string) string {
+		if v == "" {
+			return def
+		}
+		return v
+	}
+	fmt.Printf("  模板: %s\n", orDefault(lp.Template, "内置（中文 / 英文）"))
+	fmt.Printf("  标题: %s\n", orDefault(lp.Title, "默认"))
+	fmt.Printf("  说明: %s\n", orDefault(lp.Message, "无"))
+	return nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authPortalDomain string
+	authPortalOff    bool
+)
+
+func init() {
+	authPortalCmd.Flags().StringVar(&authPortalDomain, "domain", "", "父域名，登录 Cookie 对其下所有子域名生效 (如 example.com)")
+	authPortalCmd.Flags().BoolVar(&authPortalOff, "off", false, "关闭 SSO 门户")
+	authCmd.AddCommand(authPortalCmd)
+}
+
+var authPortalCmd = &cobra.Command{
+	Use:   "portal <路由> --domain <父域名>",
+	Short: "将路由设为 SSO 门户（如 auth.example.com），供其他路由统一登录",
+	Long: `门户路由使用自身的用户、htpasswd 或 OIDC 登录，登录后签发父域名 Cookie 并跳回来源地址。
+门户只负责登录，不再转发请求到后端服务，创建门户路由时端口可任意填写：
+  cftunnel add auth 9000 --domain auth.example.com --auth admin:密码
+  cftunnel auth portal auth --domain example.com
+  cftunnel auth sso app --portal auth`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		domain := strings.ToLower(strings.TrimPrefix(authPortalDomain, "."))
+		if !authPortalOff && domain == "" {
+			return fmt.Errorf("请指定 --domain，或使用 --off 关闭")
+		}
+		var latest *config.Config
+		err := config.Update(func(cfg *config.Config) error {
+			latest = cfg
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if authPortalOff {
+				if route.Auth == nil || route.Auth.PortalDomain == "" {
+					return fmt.Errorf("路由 %s 未启用 SSO 门户", routeName)
+				}
+				for _, r := range cfg.Routes {
+					if r.Auth != nil && r.Auth.SSO == routeName {
+						return fmt.Errorf("路由 %s 仍在使用该门户，请先执行 cftunnel auth sso %s --off", r.Name, r.Name)
+					}
+				}
+				route.Auth.PortalDomain = ""
+				return nil
+			}
+			if route.Auth == nil || !hasLoginMethod(route.Auth) {
+				return fmt.Errorf("门户需要登录方式，请先为路由 %s 配置 --auth / --htpasswd / --oidc-*", routeName)
+			}
+			if route.Auth.SSO != "" {
+				return fmt.Errorf("路由 %s 正在使用其他门户登录", routeName)
+			}
+			if !authproxy.InDomain(route.Hostname, domain) || !strings.Contains(domain, ".") {
+				return fmt.Errorf("门户域名 %s 不在父域名 %s 下", route.Hostname, domain)
+			}
+			route.Auth.PortalDomain = domain
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if authPortalOff {
+			fmt.Printf("✔ 已关闭 SSO 门户: %s\n", routeName)
+		} else {
+			fmt.Printf("✔ 路由 %s 已设为 SSO 门户 (*.%s)\n", routeName, domain)
+			fmt.Printf("  其他路由执行 cftunnel auth sso <路由> --portal %s 接入\n", routeName)
+			warnPortalExposure(latest, domain)
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
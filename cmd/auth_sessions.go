@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var authSessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "管理路由的登录会话（查看、吊销）",
+}
+
+func init() {
+	authCmd.AddCommand(authSessionsCmd)
+}
+
+// revokeUserSessions 吊销用户在路由上的全部会话（修改密码或删除用户后调用）
+func revokeUserSessions(profile, routeName, username string) {
+	n, err := session.Open(profile, routeName).Remove(func(s session.Session) bool {
+		return s.User == username
+	})
+	if err != nil {
+		fmt.Printf("警告: 吊销 %s 的会话失败: %v\n", username, err)
+		return
+	}
+	if n > 0 {
+		fmt.Printf("已吊销 %s 的 %d 个会话\n", username, n)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authSessionsCmd.AddCommand(authSessionsListCmd)
+}
+
+var authSessionsListCmd = &cobra.Command{
+	Use:   "list <路由>",
+	Short: "列出路由当前有效的登录会话",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if _, err := findAuthRoute(cfg, args[0]); err != nil {
+			return err
+		}
+		sessions := session.Open(cfg.ActiveProfile(), args[0]).List()
+		if len(sessions) == 0 {
+			fmt.Printf("路由 %s 暂无登录会话\n", args[0])
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\t用户\t来源 IP\t登录时间\t过期时间")
+		fmt.Fprintln(w, "--\t----\t-------\t--------\t--------")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.ID, s.User, s.IP,
+				s.Created.Local().Format("2006-01-02 15:04"), s.Expires.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+		return nil
+	},
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sessionsRevokeUser string
+	sessionsRevokeAll  bool
+)
+
+func init() {
+	authSessionsRevokeCmd.Flags().StringVar(&sessionsRevokeUser, "user", "", "吊销该用户的全部会话")
+	authSessionsRevokeCmd.Flags().BoolVar(&sessionsRevokeAll, "all", false, "吊销路由的全部会话")
+	authSessionsCmd.AddCommand(authSessionsRevokeCmd)
+}
+
+var authSessionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <路由> [会话ID...]",
+	Short: "吊销登录会话，无需重启隧道即时生效",
+	Long: `吊销指定的登录会话，被吊销的浏览器需要重新登录。
+  cftunnel auth sessions revoke app 3f9a...        # 按会话 ID 吊销
+  cftunnel auth sessions revoke app --user alice   # 吊销某用户的全部会话
+  cftunnel auth sessions revoke app --all          # 吊销全部会话`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName, ids := args[0], args[1:]
+		modes := 0
+		for _, set := range []bool{len(ids) > 0, sessionsRevokeUser != "", sessionsRevokeAll} {
+			if set {
+				modes++
+			}
+		}
+		if modes != 1 {
+			return fmt.Errorf("请指定会话 ID、--user 或 --all 其中之一")
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if _, err := findAuthRoute(cfg, routeName); err != nil {
+			return err
+		}
+		n, err := session.Open(cfg.ActiveProfile(), routeName).Remove(func(s session.Session) bool {
+			switch {
+			case sessionsRevokeAll:
+				return true
+			case sessionsRevokeUser != "":
+				return s.User == sessionsRevokeUser
+			default:
+				return slices.Contains(ids, s.ID)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("吊销会话失败: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("路由 %s 中没有匹配的会话", routeName)
+		}
+		fmt.Printf("✔ 已吊销 %d 个会话 (%s)\n", n, routeName)
+		return nil
+	},
+}
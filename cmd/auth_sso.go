@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authSSOPortal string
+	authSSOOff    bool
+)
+
+func init() {
+	authSSOCmd.Flags().StringVar(&authSSOPortal, "portal", "", "SSO 门户路由名称（先通过 cftunnel auth portal 启用）")
+	authSSOCmd.Flags().BoolVar(&authSSOOff, "off", false, "关闭单点登录")
+	authCmd.AddCommand(authSSOCmd)
+}
+
+var authSSOCmd = &cobra.Command{
+	Use:   "sso <路由> --portal <门户路由>",
+	Short: "路由改由 SSO 门户登录，同一父域名下登录一次即可访问所有路由",
+	Long: `路由接受 SSO 门户签发的父域名 Cookie，未登录的浏览器跳转到门户登录后自动跳回。
+路由域名必须位于门户的父域名下；路由自身的用户和 API Key 仍可用于 Basic / Bearer 访问。
+在门户路由上执行 cftunnel auth sessions revoke 可一并吊销所有路由的登录状态。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		if !authSSOOff && authSSOPortal == "" {
+			return fmt.Errorf("请指定 --portal，或使用 --off 关闭")
+		}
+		var portal *config.RouteConfig
+		var latest *config.Config
+		err := config.Update(func(cfg *config.Config) error {
+			latest = cfg
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if authSSOOff {
+				if route.Auth == nil || route.Auth.SSO == "" {
+					return fmt.Errorf("路由 %s 未启用单点登录", routeName)
+				}
+				route.Auth.SSO = ""
+				if !hasLoginMethod(route.Auth) && len(route.Auth.APIKeys) == 0 {
+					route.Auth = nil
+				}
+				return nil
+			}
+			if route.Auth == nil {
+				route.Auth = &config.AuthProxy{SigningKey: hex.EncodeToString(authproxy.RandomKey())}
+			}
+			if route.Auth.PortalDomain != "" {
+				return fmt.Errorf("路由 %s 本身是 SSO 门户", routeName)
+			}
+			route.Auth.SSO = authSSOPortal
+			portal, err = ssoPortal(cfg, route)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if authSSOOff {
+			fmt.Printf("✔ 已关闭单点登录: %s\n", routeName)
+		} else {
+			fmt.Printf("✔ 路由 %s 已改由门户 %s (%s) 登录\n", routeName, portal.Name, portal.Hostname)
+			warnPortalExposure(latest, portal.Auth.PortalDomain)
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
+
+// ssoPortal 返回路由使用的 SSO 门户，并校验门户已启用且路由域名位于门户父域名下
+func ssoPortal(cfg *config.Config, r *config.RouteConfig) (*config.RouteConfig, error) {
+	portal := cfg.FindRoute(r.Auth.SSO)
+	if portal == nil {
+		return nil, fmt.Errorf("路由 %s 使用的 SSO 门户 %s 不存在", r.Name, r.Auth.SSO)
+	}
+	if portal.Auth == nil || portal.Auth.PortalDomain == "" {
+		return nil, fmt.Errorf("路由 %s 未启用 SSO 门户，请先执行 cftunnel auth portal %s --domain <父域名>", portal.Name, portal.Name)
+	}
+	if !authproxy.InDomain(r.Hostname, portal.Auth.PortalDomain) {
+		return nil, fmt.Errorf("路由 %s 的域名 %s 不在门户父域名 %s 下", r.Name, r.Hostname, portal.Auth.PortalDomain)
+	}
+	return portal, nil
+}
+
+// hasLoginMethod 是否配置了用户、htpasswd 或 OIDC 登录
+func hasLoginMethod(a *config.AuthProxy) bool {
+	return len(a.Users) > 0 || a.HtpasswdFile != "" || a.OIDC != nil
+}
+
+// portalDomainOf 返回域名所在的 SSO 门户父域名，不在任何门户下时返回空
+func portalDomainOf(cfg *config.Config, host string) string {
+	for _, r := range cfg.Routes {
+		if r.Auth != nil && r.Auth.PortalDomain != "" && authproxy.InDomain(host, r.Auth.PortalDomain) {
+			return r.Auth.PortalDomain
+		}
+	}
+	return ""
+}
+
+// warnPortalExposure 提示门户父域名下 Cookie 的暴露范围：
+// 本隧道未启用鉴权的路由会经代理删除门户 Cookie 后再转发，不经本隧道的站点仍会收到该 Cookie
+func warnPortalExposure(cfg *config.Config, domain string) {
+	var open []string
+	for _, r := range cfg.Routes {
+		if r.Auth == nil && authproxy.InDomain(r.Hostname, domain) {
+			open = append(open, r.Name)
+		}
+	}
+	if len(open) > 0 {
+		fmt.Printf("  注意: 未启用鉴权的路由 %s 位于 %s 下，隧道运行时将经代理转发以删除门户 Cookie\n", strings.Join(open, "、"), domain)
+	}
+	fmt.Printf("  注意: 浏览器会把门户 Cookie 发送给 *.%s 下的所有站点，不经本隧道的站点可读取并冒用登录状态，请确保它们可信\n", domain)
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var authTOTPCmd = &cobra.Command{
+	Use:   "totp",
+	Short: "管理用户两步验证（TOTP）",
+}
+
+func init() {
+	authCmd.AddCommand(authTOTPCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authTOTPCmd.AddCommand(authTOTPDisableCmd)
+}
+
+var authTOTPDisableCmd = &cobra.Command{
+	Use:   "disable <路由> <用户名>",
+	Short: "关闭用户的两步验证",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName, username := args[0], args[1]
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			var u *config.AuthUser
+			if route.Auth != nil {
+				u = route.Auth.FindUser(username)
+			}
+			if u == nil || u.TOTPSecret == "" {
+				return fmt.Errorf("用户 %s 未启用两步验证 (%s)", username, routeName)
+			}
+			u.TOTPSecret = ""
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 已关闭 %s 的两步验证 (%s)\n", username, routeName)
+		printAuthReloadHint()
+		return nil
+	},
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mdp/qrterminal/v3"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/totp"
+	"github.com/spf13/cobra"
+)
+
+var authTOTPSkipVerify bool
+
+func init() {
+	authTOTPEnrollCmd.Flags().BoolVar(&authTOTPSkipVerify, "skip-verify", false, "不输入验证码确认，直接启用（用于脚本）")
+	authTOTPCmd.AddCommand(authTOTPEnrollCmd)
+}
+
+var authTOTPEnrollCmd = &cobra.Command{
+	Use:   "enroll <路由> <用户名>",
+	Short: "为用户启用两步验证（已启用时重新生成密钥）",
+	Long: `生成 TOTP 密钥并显示 otpauth:// 地址和二维码，使用 Google Authenticator、1Password 等验证器 App 扫描。
+启用后登录页需同时输入 6 位验证码（允许前后 30 秒时钟偏差，同一验证码只能使用一次）；
+该用户将无法通过 Authorization: Basic 访问，脚本请改用 API Key。`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName, username := args[0], args[1]
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		route, err := findAuthRoute(cfg, routeName)
+		if err != nil {
+			return err
+		}
+		if route.Auth == nil || route.Auth.FindUser(username) == nil {
+			return fmt.Errorf("路由 %s 中不存在用户 %s（htpasswd 用户不支持两步验证）", routeName, username)
+		}
+
+		secret := totp.GenerateSecret()
+		uri := totp.URI("cftunnel", username+"@"+route.Hostname, secret)
+		fmt.Println("使用验证器 App 扫描二维码:")
+		qrterminal.GenerateHalfBlock(uri, qrterminal.L, os.Stdout)
+		fmt.Printf("\n无法扫描时手动输入密钥: %s\n", secret)
+		fmt.Printf("otpauth 地址: %s\n\n", uri)
+
+		if !authTOTPSkipVerify {
+			var code string
+			err := huh.NewInput().Title("输入 App 显示的 6 位验证码确认").Value(&code).Run()
+			if err != nil {
+				return err
+			}
+			if _, ok := totp.Verify(secret, code, time.Now()); !ok {
+				return fmt.Errorf("验证码错误，两步验证未启用，请检查设备时间后重试")
+			}
+		}
+
+		err = config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			var u *config.AuthUser
+			if route.Auth != nil {
+				u = route.Auth.FindUser(username)
+			}
+			if u == nil {
+				return fmt.Errorf("路由 %s 中不存在用户 %s", routeName, username)
+			}
+			u.TOTPSecret = secret
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 已为 %s 启用两步验证 (%s)\n", username, routeName)
+		printAuthReloadHint()
+		return nil
+	},
+}
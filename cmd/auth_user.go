@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var authUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "管理路由鉴权用户",
+}
+
+func init() {
+	authCmd.AddCommand(authUserCmd)
+}
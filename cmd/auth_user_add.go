@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/passwd"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authUserAddPassword string
+	authUserAddGroups   []string
+)
+
+func init() {
+	authUserAddCmd.Flags().StringVar(&authUserAddPassword, "password", "", "用户密码（不指定时交互输入）")
+	authUserAddCmd.Flags().StringSliceVar(&authUserAddGroups, "group", nil, "所属组，转发给后端的 X-Forwarded-Groups（可重复，已有用户指定时替换）")
+	authUserCmd.AddCommand(authUserAddCmd)
+}
+
+var authUserAddCmd = &cobra.Command{
+	Use:   "add <路由> <用户名>",
+	Short: "添加用户（已存在时更新密码，路由未启用鉴权时自动启用）",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName, username := args[0], args[1]
+		pass := authUserAddPassword
+		if pass == "" {
+			var err error
+			if pass, err = promptUserPassword(); err != nil {
+				return err
+			}
+		}
+		hash, err := passwd.Hash(pass)
+		if err != nil {
+			return err
+		}
+
+		updated := false
+		var profile string
+		err = config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			profile = cfg.ActiveProfile()
+			if route.Auth == nil {
+				route.Auth = &config.AuthProxy{SigningKey: hex.EncodeToString(authproxy.RandomKey())}
+				fmt.Printf("已启用密码保护: %s\n", route.Hostname)
+			}
+			if u := route.Auth.FindUser(username); u != nil {
+				u.Password = hash
+				if cmd.Flags().Changed("group") {
+					u.Groups = authUserAddGroups
+				}
+				updated = true
+			} else {
+				route.Auth.Users = append(route.Auth.Users, config.AuthUser{Username: username, Password: hash, Groups: authUserAddGroups})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if updated {
+			fmt.Printf("✔ 用户 %s 的密码已更新 (%s)\n", username, routeName)
+			revokeUserSessions(profile, routeName, username)
+		} else {
+			fmt.Printf("✔ 用户已添加: %s (%s)\n", username, routeName)
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
+
+// promptUserPassword 交互式输入用户密码并确认
+func promptUserPassword() (string, error) {
+	var pass, again string
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewInput().Title("密码").EchoMode(huh.EchoModePassword).Value(&pass),
+		huh.NewInput().Title("确认密码").EchoMode(huh.EchoModePassword).Value(&again),
+	)).Run()
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", fmt.Errorf("密码不能为空")
+	}
+	if pass != again {
+		return "", fmt.Errorf("两次输入的密码不一致")
+	}
+	return pass, nil
+}
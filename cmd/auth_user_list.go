@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/passwd"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authUserCmd.AddCommand(authUserListCmd)
+}
+
+var authUserListCmd = &cobra.Command{
+	Use:   "list <路由>",
+	Short: "列出路由的鉴权用户（含 htpasswd 文件中的用户）",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		route, err := findAuthRoute(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		if route.Auth == nil {
+			fmt.Printf("路由 %s 未启用鉴权\n", args[0])
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "用户名\t来源\t两步验证\t组")
+		fmt.Fprintln(w, "------\t----\t--------\t--")
+		for _, u := range route.Auth.Users {
+			totp := "-"
+			if u.TOTPSecret != "" {
+				totp = "✓"
+			}
+			groups := "-"
+			if len(u.Groups) > 0 {
+				groups = strings.Join(u.Groups, ",")
+			}
+			fmt.Fprintf(w, "%s\t配置文件\t%s\t%s\n", u.Username, totp, groups)
+		}
+		if f := route.Auth.HtpasswdFile; f != "" {
+			users, err := passwd.ParseHtpasswd(f)
+			if err != nil {
+				w.Flush()
+				return fmt.Errorf("读取 htpasswd 文件失败: %w", err)
+			}
+			var names []string
+			for name := range users {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				src := f
+				if route.Auth.FindUser(name) != nil {
+					src += "（被配置文件中的同名用户覆盖）"
+				}
+				fmt.Fprintf(w, "%s\t%s\t-\t-\n", name, src)
+			}
+		}
+		w.Flush()
+		return nil
+	},
+}
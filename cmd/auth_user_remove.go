@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	authUserCmd.AddCommand(authUserRemoveCmd)
+}
+
+var authUserRemoveCmd = &cobra.Command{
+	Use:   "remove <路由> <用户名>",
+	Short: "删除用户（该用户已登录的会话随之失效）",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName, username := args[0], args[1]
+		var profile string
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			profile = cfg.ActiveProfile()
+			if route.Auth == nil || route.Auth.FindUser(username) == nil {
+				return fmt.Errorf("路由 %s 中不存在用户 %s", routeName, username)
+			}
+			if len(route.Auth.Users) == 1 && route.Auth.HtpasswdFile == "" {
+				return fmt.Errorf("%s 是路由 %s 的最后一个用户，删除后将无人可登录，请先添加其他用户", username, routeName)
+			}
+			route.Auth.RemoveUser(username)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 用户已删除: %s (%s)\n", username, routeName)
+		revokeUserSessions(profile, routeName, username)
+		printAuthReloadHint()
+		return nil
+	},
+}
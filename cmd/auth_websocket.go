@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var authWSAnonymous bool
+
+func init() {
+	authWebSocketCmd.Flags().BoolVar(&authWSAnonymous, "anonymous", false, "允许未登录的 WebSocket 连接（--anonymous=false 恢复鉴权）")
+	authWebSocketCmd.MarkFlagRequired("anonymous")
+	authCmd.AddCommand(authWebSocketCmd)
+}
+
+var authWebSocketCmd = &cobra.Command{
+	Use:   "websocket <路由> --anonymous[=false]",
+	Short: "设置 WebSocket 连接是否需要登录（默认需要，未登录返回 401）",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		err := config.Update(func(cfg *config.Config) error {
+			route, err := findAuthRoute(cfg, routeName)
+			if err != nil {
+				return err
+			}
+			if route.Auth == nil {
+				return fmt.Errorf("路由 %s 未启用鉴权", routeName)
+			}
+			route.Auth.AnonymousWS = authWSAnonymous
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if authWSAnonymous {
+			fmt.Printf("✔ 路由 %s 允许未登录的 WebSocket 连接\n", routeName)
+		} else {
+			fmt.Printf("✔ 路由 %s 的 WebSocket 连接需要登录\n", routeName)
+		}
+		printAuthReloadHint()
+		return nil
+	},
+}
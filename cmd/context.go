@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "管理多套配置上下文（账户 / 隧道 / 中继）",
+	Long:  "每个上下文保存一套独立的 Cloudflare 账户、隧道、路由和中继配置。\n通过 cftunnel context use 切换默认上下文，或使用全局参数 --profile / 环境变量 CFTUNNEL_PROFILE 临时指定。",
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+}
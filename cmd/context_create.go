@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var contextCreateUse bool
+
+func init() {
+	contextCreateCmd.Flags().BoolVar(&contextCreateUse, "use", false, "创建后立即切换到该上下文")
+	contextCmd.AddCommand(contextCreateCmd)
+}
+
+var contextCreateCmd = &cobra.Command{
+	Use:   "create <名称>",
+	Short: "新建空白上下文",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		err := config.UpdateProfile(config.DefaultProfile, func(cfg *config.Config) error {
+			if err := cfg.CreateProfile(name); err != nil {
+				return err
+			}
+			if contextCreateUse {
+				cfg.UseProfile(name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 上下文已创建: %s\n", name)
+		fmt.Printf("\n下一步: cftunnel --profile %s init\n", name)
+		return nil
+	},
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	contextCmd.AddCommand(contextDeleteCmd)
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:   "delete <名称>",
+	Short: "删除上下文（仅删除本地配置，不清理远端隧道和 DNS）",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		err := config.UpdateProfile(config.DefaultProfile, func(cfg *config.Config) error {
+			if name == cfg.SelectedProfile() && name != config.DefaultProfile {
+				fmt.Printf("上下文 %s 当前已选中，删除后将回到 default\n", name)
+			}
+			return cfg.DeleteProfile(name)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 上下文已删除: %s\n", name)
+		return nil
+	},
+}
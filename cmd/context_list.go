@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	contextCmd.AddCommand(contextListCmd)
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有上下文",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadProfile(config.DefaultProfile)
+		if err != nil {
+			return err
+		}
+		selected := cfg.SelectedProfile()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\t名称\t隧道\t路由\t中继规则")
+		fmt.Fprintln(w, "\t----\t----\t----\t--------")
+		for _, name := range cfg.ProfileNames() {
+			p := cfg.FindProfile(name)
+			mark := ""
+			if name == selected {
+				mark = "*"
+			}
+			tunnel := "-"
+			if p.Tunnel.Name != "" {
+				tunnel = p.Tunnel.Name
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", mark, name, tunnel, len(p.Routes), len(p.Relay.Rules))
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	contextCmd.AddCommand(contextUseCmd)
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <名称>",
+	Short: "切换默认上下文",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := config.UpdateProfile(config.DefaultProfile, func(cfg *config.Config) error {
+			return cfg.UseProfile(args[0])
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 已切换到上下文: %s\n", args[0])
+		return nil
+	},
+}
@@ -44,8 +44,11 @@ var createCmd = &cobra.Command{
 			return err
 		}
 
-		cfg.Tunnel = config.TunnelConfig{ID: tunnel.ID, Name: tunnel.Name, Token: token}
-		if err := cfg.Save(); err != nil {
+		err = config.Update(func(latest *config.Config) error {
+			latest.Tunnel = config.TunnelConfig{ID: tunnel.ID, Name: tunnel.Name, Token: token}
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 		fmt.Println("\n下一步: cftunnel add <名称> <端口> --domain <域名>")
@@ -69,9 +69,12 @@ var destroyCmd = &cobra.Command{
 		}
 
 		// 清空配置
-		cfg.Tunnel = config.TunnelConfig{}
-		cfg.Routes = nil
-		if err := cfg.Save(); err != nil {
+		err = config.Update(func(latest *config.Config) error {
+			latest.Tunnel = config.TunnelConfig{}
+			latest.Routes = nil
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 
@@ -64,9 +64,11 @@ var initCmd = &cobra.Command{
 			return fmt.Errorf("API 令牌和账户 ID 不能为空")
 		}
 
-		cfg, _ := config.Load()
-		cfg.Auth = config.AuthConfig{APIToken: apiToken, AccountID: accountID}
-		if err := cfg.Save(); err != nil {
+		err := config.Update(func(cfg *config.Config) error {
+			cfg.Auth = config.AuthConfig{APIToken: apiToken, AccountID: accountID}
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 		fmt.Printf("认证信息已保存到 %s\n", config.Path())
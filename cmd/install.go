@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/qingchencloud/cftunnel/internal/config"
 	"github.com/qingchencloud/cftunnel/internal/daemon"
@@ -9,7 +11,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var installPassphraseFromEnv bool
+
 func init() {
+	installCmd.Flags().BoolVar(&installPassphraseFromEnv, "passphrase-from-env", false, "将 CFTUNNEL_PASSPHRASE 写入服务环境（口令加密的配置需要）")
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(uninstallCmd)
 }
@@ -28,15 +33,47 @@ var installCmd = &cobra.Command{
 		if cfg.Tunnel.Token == "" {
 			return fmt.Errorf("请先运行 cftunnel init && cftunnel create <名称>")
 		}
-		binPath, err := daemon.EnsureCloudflared()
+		// 提前下载 cloudflared，避免服务首次启动时联网下载
+		if _, err := daemon.EnsureCloudflared(); err != nil {
+			return err
+		}
+		exe, err := os.Executable()
 		if err != nil {
 			return err
 		}
+		if real, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = real
+		}
+		// 服务运行 cftunnel run 守护进程，托管 cloudflared 和鉴权代理
+		env := map[string]string{"CFTUNNEL_HOME": config.Dir()}
+		if p := cfg.ActiveProfile(); p != config.DefaultProfile {
+			env["CFTUNNEL_PROFILE"] = p
+		}
+		// 服务没有终端和登录会话，无法交互输入口令，也可能访问不到用户的密钥环
+		switch cfg.Secrets.Provider {
+		case config.SecretsPassphrase:
+			pass := os.Getenv("CFTUNNEL_PASSPHRASE")
+			if !installPassphraseFromEnv || pass == "" {
+				return fmt.Errorf("配置使用口令加密，系统服务无法输入口令：请设置 CFTUNNEL_PASSPHRASE 并加 --passphrase-from-env（口令将以明文保存在服务定义中），或先执行 cftunnel secrets rotate --provider keyring")
+			}
+			env["CFTUNNEL_PASSPHRASE"] = pass
+		case config.SecretsKeyring:
+			fmt.Println("警告: 配置使用系统密钥环加密，服务在无登录会话时（如 Linux 开机自启）可能无法读取密钥环；" +
+				"若服务启动失败，请改用 cftunnel secrets rotate --provider passphrase 并以 --passphrase-from-env 重新注册")
+		}
 		svc := service.New()
-		if err := svc.Install(binPath, cfg.Tunnel.Token); err != nil {
+		err = svc.Install(service.Command{
+			Path: exe,
+			Args: []string{"run"},
+			Env:  env,
+		})
+		if err != nil {
 			return fmt.Errorf("注册服务失败: %w", err)
 		}
 		fmt.Println("系统服务已注册，隧道将开机自启")
+		if env["CFTUNNEL_PASSPHRASE"] != "" {
+			fmt.Printf("警告: 口令已明文写入 %s；能读取该位置的用户即可解密配置中的密钥\n", svc.EnvLocation())
+		}
 		return nil
 	},
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/qingchencloud/cftunnel/internal/config"
@@ -36,9 +37,25 @@ var listCmd = &cobra.Command{
 			fmt.Fprintln(w, "名称\t域名\t服务\t鉴权")
 			fmt.Fprintln(w, "----\t----\t----\t----")
 			for _, r := range cfg.Routes {
-				auth := "-"
+				var marks []string
 				if r.Auth != nil {
-					auth = "✓"
+					marks = append(marks, "✓")
+					if r.Auth.PortalDomain != "" {
+						marks = append(marks, "SSO 门户")
+					}
+					if r.Auth.SSO != "" {
+						marks = append(marks, "SSO("+r.Auth.SSO+")")
+					}
+				}
+				if r.Access != nil {
+					marks = append(marks, "Access")
+				}
+				if r.IPFilter != nil {
+					marks = append(marks, "IP")
+				}
+				auth := "-"
+				if len(marks) > 0 {
+					auth = strings.Join(marks, " + ")
 				}
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.Hostname, r.Service, auth)
 			}
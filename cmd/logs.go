@@ -2,49 +2,42 @@ package cmd
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
-	"runtime"
+	"strings"
 	"time"
 
-	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/daemon"
 	"github.com/spf13/cobra"
 )
 
 var follow bool
+var logsAuth bool
+var logsAccess string
 
 func init() {
 	logsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "实时跟踪日志")
+	logsCmd.Flags().BoolVar(&logsAuth, "auth", false, "查看鉴权失败与锁定记录")
+	logsCmd.Flags().StringVar(&logsAccess, "access", "", "查看指定路由的访问日志")
 	rootCmd.AddCommand(logsCmd)
 }
 
-// logFilePath 根据操作系统返回日志文件路径
-func logFilePath() string {
-	// 便携模式：日志放在程序同级目录
-	if config.Portable() {
-		return filepath.Join(config.Dir(), "cftunnel.log")
-	}
-	// 普通模式：按 OS 惯例
-	home, _ := os.UserHomeDir()
-	switch runtime.GOOS {
-	case "darwin":
-		return filepath.Join(home, "Library/Logs/cftunnel.log")
-	case "windows":
-		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
-			return filepath.Join(dir, "cftunnel", "cftunnel.log")
-		}
-		return filepath.Join(home, ".cftunnel", "cftunnel.log")
-	default:
-		return filepath.Join(home, ".local/share/cftunnel/cftunnel.log")
-	}
-}
-
 var logsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "查看隧道日志",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		logFile := logFilePath()
+		logFile := daemon.LogFilePath()
+		var match func(string) bool // nil 表示不过滤
+		switch {
+		case logsAuth && logsAccess != "":
+			return fmt.Errorf("--auth 和 --access 不能同时使用")
+		case logsAuth:
+			logFile = daemon.AuthLogPath()
+		case logsAccess != "":
+			logFile = daemon.AccessLogPath()
+			match = func(line string) bool { return accessLineRoute(line) == logsAccess }
+		}
 		f, err := os.Open(logFile)
 		if err != nil {
 			return fmt.Errorf("日志文件不存在: %s", logFile)
@@ -52,7 +45,7 @@ var logsCmd = &cobra.Command{
 		defer f.Close()
 
 		// 读取最后 100 行
-		lines, err := tailLines(f, 100)
+		lines, err := tailLines(f, 100, match)
 		if err != nil {
 			return err
 		}
@@ -73,11 +66,17 @@ var logsCmd = &cobra.Command{
 				continue
 			}
 			stat2, _ := f2.Stat()
+			// 文件被轮转后从头读取
+			if stat2.Size() < offset {
+				offset = 0
+			}
 			if stat2.Size() > offset {
 				f2.Seek(offset, 0)
 				scanner := bufio.NewScanner(f2)
 				for scanner.Scan() {
-					fmt.Println(scanner.Text())
+					if match == nil || match(scanner.Text()) {
+						fmt.Println(scanner.Text())
+					}
 				}
 				offset = stat2.Size()
 			}
@@ -87,11 +86,14 @@ var logsCmd = &cobra.Command{
 	},
 }
 
-// tailLines 读取文件最后 n 行
-func tailLines(f *os.File, n int) ([]string, error) {
+// tailLines 读取文件最后 n 行，match 非 nil 时只保留匹配的行
+func tailLines(f *os.File, n int, match func(string) bool) ([]string, error) {
 	scanner := bufio.NewScanner(f)
 	var lines []string
 	for scanner.Scan() {
+		if match != nil && !match(scanner.Text()) {
+			continue
+		}
 		lines = append(lines, scanner.Text())
 		if len(lines) > n {
 			lines = lines[1:]
@@ -99,3 +101,18 @@ func tailLines(f *os.File, n int) ([]string, error) {
 	}
 	return lines, scanner.Err()
 }
+
+// accessLineRoute 解析访问日志行所属的路由（JSON 取 route 字段，combined 取末尾字段）
+func accessLineRoute(line string) string {
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Route string `json:"route"`
+		}
+		json.Unmarshal([]byte(line), &entry)
+		return entry.Route
+	}
+	if i := strings.LastIndex(line, " "); i >= 0 {
+		return line[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var planFile string
+
+func init() {
+	planCmd.Flags().StringVarP(&planFile, "file", "f", "", "清单文件路径 (YAML)")
+	planCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(planCmd)
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan -f <清单>",
+	Short: "预览清单将产生的 DNS / ingress / frpc 变更",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		plan, err := loadPlan(cfg, planFile)
+		if err != nil {
+			return err
+		}
+		plan.Print(os.Stdout)
+		return nil
+	},
+}
+
+// loadPlan 读取清单并与当前配置对比
+func loadPlan(cfg *config.Config, path string) (*manifest.Plan, error) {
+	m, err := manifest.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Compute(cfg, m)
+}
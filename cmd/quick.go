@@ -4,19 +4,26 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
 	"github.com/qingchencloud/cftunnel/internal/daemon"
+	"github.com/qingchencloud/cftunnel/internal/inspector"
 	"github.com/qingchencloud/cftunnel/internal/relay"
 	"github.com/spf13/cobra"
 )
 
 var (
-	quickAuth  string
-	quickRelay bool
-	quickProto string
+	quickAuth        string
+	quickPublicPaths []string
+	quickRelay       bool
+	quickProto       string
+	quickOpts        daemon.QuickOptions
 )
 
 func init() {
 	quickCmd.Flags().StringVar(&quickAuth, "auth", "", "启用密码保护 (格式: 用户名:密码)")
+	quickCmd.Flags().StringSliceVar(&quickPublicPaths, "public-path", nil, "无需鉴权的路径，前缀或通配符（可重复），仅 --auth 时有效")
+	quickCmd.Flags().BoolVar(&quickOpts.Inspect, "inspect", false, "启用本地请求检查器（Web UI 查看、过滤和重放请求）")
+	quickCmd.Flags().IntVar(&quickOpts.InspectPort, "inspect-port", inspector.DefaultUIPort, "请求检查器 Web UI 端口（仅监听 127.0.0.1，被占用时顺延）")
 	quickCmd.Flags().BoolVar(&quickRelay, "relay", false, "使用中继模式穿透（需先 relay init）")
 	quickCmd.Flags().StringVar(&quickProto, "proto", "tcp", "中继协议 (tcp/udp)，仅 --relay 时有效")
 	rootCmd.AddCommand(quickCmd)
@@ -29,6 +36,9 @@ var quickCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if quickRelay {
+			if quickOpts.Inspect {
+				return fmt.Errorf("--inspect 不支持 --relay 模式")
+			}
 			return relay.StartQuick(args[0], quickProto)
 		}
 		if quickAuth != "" {
@@ -36,9 +46,15 @@ var quickCmd = &cobra.Command{
 			if err != nil {
 				return err
 			}
-			return daemon.StartQuickWithAuth(args[0], user, pass)
+			if err := validatePublicPaths(quickPublicPaths); err != nil {
+				return err
+			}
+			return daemon.StartQuickWithAuth(args[0], user, pass, quickPublicPaths, quickOpts)
+		}
+		if len(quickPublicPaths) > 0 {
+			return fmt.Errorf("--public-path 需与 --auth 同时使用")
 		}
-		return daemon.StartQuick(args[0])
+		return daemon.StartQuick(args[0], quickOpts)
 	},
 }
 
@@ -55,3 +71,13 @@ func parseAuth(s string) (string, string, error) {
 	}
 	return user, pass, nil
 }
+
+// validatePublicPaths 校验 --public-path 参数
+func validatePublicPaths(patterns []string) error {
+	for _, pattern := range patterns {
+		if err := authproxy.ValidatePublicPath(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -29,28 +29,25 @@ var relayAddCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		cfg, err := config.Load()
+		err := config.Update(func(cfg *config.Config) error {
+			if cfg.Relay.Server == "" {
+				return fmt.Errorf("未配置中继服务器，请先执行 cftunnel relay init")
+			}
+			if cfg.FindRelayRule(name) != nil {
+				return fmt.Errorf("规则 %q 已存在", name)
+			}
+			cfg.Relay.Rules = append(cfg.Relay.Rules, config.RelayRule{
+				Name:       name,
+				Proto:      relayAddProto,
+				LocalPort:  relayAddLocal,
+				RemotePort: relayAddRemote,
+				Domain:     relayAddDomain,
+			})
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		if cfg.Relay.Server == "" {
-			return fmt.Errorf("未配置中继服务器，请先执行 cftunnel relay init")
-		}
-		if cfg.FindRelayRule(name) != nil {
-			return fmt.Errorf("规则 %q 已存在", name)
-		}
-
-		rule := config.RelayRule{
-			Name:       name,
-			Proto:      relayAddProto,
-			LocalPort:  relayAddLocal,
-			RemotePort: relayAddRemote,
-			Domain:     relayAddDomain,
-		}
-		cfg.Relay.Rules = append(cfg.Relay.Rules, rule)
-		if err := cfg.Save(); err != nil {
-			return err
-		}
 
 		desc := fmt.Sprintf("localhost:%d", relayAddLocal)
 		if relayAddRemote > 0 {
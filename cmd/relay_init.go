@@ -22,15 +22,14 @@ var relayInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "配置中继服务器连接",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load()
+		err := config.Update(func(cfg *config.Config) error {
+			cfg.Relay.Server = relayInitServer
+			cfg.Relay.Token = relayInitToken
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		cfg.Relay.Server = relayInitServer
-		cfg.Relay.Token = relayInitToken
-		if err := cfg.Save(); err != nil {
-			return err
-		}
 		fmt.Printf("✔ 中继服务器已配置: %s\n", relayInitServer)
 		return nil
 	},
@@ -28,7 +28,7 @@ var relayLogsCmd = &cobra.Command{
 		}
 		defer f.Close()
 
-		lines, err := tailLines(f, 100)
+		lines, err := tailLines(f, 100, nil)
 		if err != nil {
 			return err
 		}
@@ -17,16 +17,15 @@ var relayRemoveCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		cfg, err := config.Load()
+		err := config.Update(func(cfg *config.Config) error {
+			if !cfg.RemoveRelayRule(name) {
+				return fmt.Errorf("规则 %q 不存在", name)
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		if !cfg.RemoveRelayRule(name) {
-			return fmt.Errorf("规则 %q 不存在", name)
-		}
-		if err := cfg.Save(); err != nil {
-			return err
-		}
 		fmt.Printf("✔ 规则已删除: %s\n", name)
 		return nil
 	},
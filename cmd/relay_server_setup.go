@@ -83,15 +83,14 @@ func runRelayServerSetup(cmd *cobra.Command, args []string) error {
 	}
 
 	serverAddr := fmt.Sprintf("%s:%d", sshCfg.Host, setupFrpsPort)
-	cfg, err := config.Load()
+	err = config.Update(func(cfg *config.Config) error {
+		cfg.Relay.Server = serverAddr
+		cfg.Relay.Token = token
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	cfg.Relay.Server = serverAddr
-	cfg.Relay.Token = token
-	if err := cfg.Save(); err != nil {
-		return err
-	}
 
 	// 阶段6: 输出结果
 	fmt.Println()
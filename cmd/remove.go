@@ -3,9 +3,11 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/qingchencloud/cftunnel/internal/cfapi"
 	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/session"
 	"github.com/spf13/cobra"
 )
 
@@ -39,10 +41,15 @@ var removeCmd = &cobra.Command{
 			}
 		}
 
-		cfg.RemoveRoute(name)
-		if err := cfg.Save(); err != nil {
+		err = config.Update(func(latest *config.Config) error {
+			latest.RemoveRoute(name)
+			cfg = latest
+			return nil
+		})
+		if err != nil {
 			return err
 		}
+		os.Remove(session.Path(cfg.ActiveProfile(), name)) // 同名路由重建后不应沿用旧会话
 
 		// 推送 ingress 配置到远端
 		fmt.Println("正在同步 ingress 配置...")
@@ -10,18 +10,28 @@ import (
 
 var Version = "dev"
 
+var profileFlag string
+
 var rootCmd = &cobra.Command{
 	Use:     "cftunnel",
 	Short:   "Cloudflare Tunnel 一键管理工具",
 	Version: Version,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		checkWindowsVersion()
+		// 写入环境变量，config.Load 和后台启动的 cftunnel run 子进程均可读取
+		if profileFlag != "" {
+			os.Setenv("CFTUNNEL_PROFILE", profileFlag)
+		}
 		if config.Portable() {
 			fmt.Printf("[便携模式] 数据目录: %s\n", config.Dir())
 		}
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "使用指定上下文 (也可通过 CFTUNNEL_PROFILE 环境变量设置)")
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/cfapi"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/daemon"
+	"github.com/qingchencloud/cftunnel/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "前台运行隧道（托管 cloudflared 和鉴权代理，Ctrl+C 退出）",
+	Long:  "以前台守护模式运行：启动所有路由的鉴权代理和 cloudflared，\ncloudflared 崩溃时自动重启，收到退出信号后依次关闭。\ncftunnel up 和系统服务均通过此命令运行。",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if cfg.Tunnel.Token == "" {
+			return fmt.Errorf("请先运行 cftunnel init && cftunnel create <名称>")
+		}
+
+		// 先检查再启动代理，避免已在运行时代理端口泄漏
+		if daemon.Running() {
+			return fmt.Errorf("cloudflared 已在运行，请先执行 cftunnel down")
+		}
+		proxies, err := startAuthProxies(cfg)
+		if err != nil {
+			return err
+		}
+
+		// 启动前同步 ingress 配置到远端，确保本地与远端一致
+		if len(cfg.Routes) > 0 {
+			client := cfapi.New(cfg.Auth.APIToken, cfg.Auth.AccountID)
+			if err := pushIngressPorts(client, context.Background(), cfg, daemon.ProxyPortMap(proxies)); err != nil {
+				fmt.Printf("警告: 同步 ingress 失败: %v（将使用远端现有配置）\n", err)
+			} else {
+				fmt.Println("ingress 配置已同步")
+			}
+		}
+
+		return runSupervisor(daemon.NewSupervisor(cfg.Tunnel.Token, cfg.Tunnel.ID, proxies))
+	},
+}
+
+// startAuthProxies 为需要代理的路由启动鉴权代理，ingress 通过 pushIngressPorts 指向代理端口
+func startAuthProxies(cfg *config.Config) ([]*authproxy.Proxy, error) {
+	var proxies []*authproxy.Proxy
+	fail := func(err error) ([]*authproxy.Proxy, error) {
+		for _, p := range proxies {
+			p.Stop()
+		}
+		return nil, err
+	}
+	authLog, err := daemon.OpenAuthLog()
+	if err != nil {
+		fmt.Printf("警告: 无法打开鉴权日志: %v\n", err)
+	}
+	accessLog, err := daemon.OpenAccessLog(cfg.AccessLog)
+	if err != nil {
+		fmt.Printf("警告: 无法打开访问日志: %v\n", err)
+	}
+	// SSO 门户先启动，使用门户的路由需要引用门户代理
+	portals := make(map[string]*authproxy.Proxy)
+	for _, i := range portalsFirst(cfg.Routes) {
+		r := cfg.Routes[i]
+		if !needsProxy(cfg, r) {
+			continue
+		}
+		if r.Auth == nil && portalDomainOf(cfg, r.Hostname) != "" {
+			fmt.Printf("路由 %s 位于 SSO 门户域名下，经代理转发以删除门户 Cookie\n", r.Name)
+		}
+		// 从 service URL 提取端口
+		port := extractPort(r.Service)
+		if port == "" {
+			return fail(fmt.Errorf("路由 %s 的 service 格式无效: %s", r.Name, r.Service))
+		}
+		pc, err := proxyConfig(cfg.ActiveProfile(), r, port)
+		if err != nil {
+			return fail(err)
+		}
+		pc.AuthLog = authLog
+		pc.AccessLog = accessLog
+		if r.Auth != nil && r.Auth.SSO != "" {
+			if _, err := ssoPortal(cfg, &r); err != nil {
+				return fail(err)
+			}
+			pc.Portal = portals[r.Auth.SSO]
+		}
+		proxy, err := authproxy.New(pc)
+		if err != nil {
+			return fail(fmt.Errorf("路由 %s 启动鉴权代理失败: %w", r.Name, err))
+		}
+		if err := proxy.Start(); err != nil {
+			return fail(fmt.Errorf("路由 %s 启动鉴权代理失败: %w", r.Name, err))
+		}
+		proxies = append(proxies, proxy)
+		if pc.PortalDomain != "" {
+			portals[r.Name] = proxy
+		}
+		fmt.Printf("鉴权代理已启动: %s → 127.0.0.1:%d → 127.0.0.1:%s\n", r.Hostname, proxy.ListenPort(), port)
+	}
+	return proxies, nil
+}
+
+// needsProxy 路由是否需要经鉴权代理转发：鉴权、Access、IP 过滤、访问日志，
+// 或位于 SSO 门户父域名下（浏览器会携带门户 Cookie，需经代理删除后再转发，防止后端读取并冒用）
+func needsProxy(cfg *config.Config, r config.RouteConfig) bool {
+	return r.Auth != nil || r.Access != nil || r.IPFilter != nil || r.AccessLog || portalDomainOf(cfg, r.Hostname) != ""
+}
+
+// portalsFirst 返回路由下标，SSO 门户排在前面
+func portalsFirst(routes []config.RouteConfig) []int {
+	var portals, others []int
+	for i, r := range routes {
+		if r.Auth != nil && r.Auth.PortalDomain != "" {
+			portals = append(portals, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	return append(portals, others...)
+}
+
+// proxyConfig 将路由的鉴权配置转换为代理配置
+func proxyConfig(profile string, r config.RouteConfig, port string) (authproxy.Config, error) {
+	pc := authproxy.Config{Name: r.Name, Hostname: r.Hostname, TargetPort: port}
+	if a := r.Access; a != nil {
+		pc.Access = &authproxy.AccessConfig{TeamDomain: a.TeamDomain, AUD: a.AUD}
+	}
+	if f := r.IPFilter; f != nil {
+		pc.IPFilter = &authproxy.IPFilterConfig{
+			Allow:          f.Allow,
+			Deny:           f.Deny,
+			AllowCountries: f.AllowCountries,
+			DenyCountries:  f.DenyCountries,
+		}
+	}
+	if r.Auth == nil {
+		return pc, nil
+	}
+
+	sigKey, err := hex.DecodeString(r.Auth.SigningKey)
+	if err != nil {
+		return pc, fmt.Errorf("路由 %s 的 signing_key 无效: %w", r.Name, err)
+	}
+	pc.SigningKey = sigKey
+	pc.CookieTTL = time.Duration(r.Auth.CookieTTLOrDefault()) * time.Second
+	pc.HtpasswdFile = r.Auth.HtpasswdFile
+	pc.AnonymousWS = r.Auth.AnonymousWS
+	pc.PublicPaths = r.Auth.PublicPaths
+	pc.PortalDomain = r.Auth.PortalDomain
+	pc.Users = make(map[string]string, len(r.Auth.Users))
+	pc.TOTP = make(map[string]string)
+	pc.Groups = make(map[string][]string)
+	for _, u := range r.Auth.Users {
+		pc.Users[u.Username] = u.Password
+		if u.TOTPSecret != "" {
+			pc.TOTP[u.Username] = u.TOTPSecret
+		}
+		if len(u.Groups) > 0 {
+			pc.Groups[u.Username] = u.Groups
+		}
+	}
+	if h := r.Auth.Headers; h != nil {
+		pc.Headers = authproxy.IdentityHeaders{User: h.User, Email: h.Email, Groups: h.Groups, Secret: []byte(h.Secret)}
+	}
+	if lp := r.Auth.LoginPage; lp != nil {
+		pc.LoginTemplate, pc.LoginTitle, pc.LoginMessage = lp.Template, lp.Title, lp.Message
+	}
+	pc.Sessions = session.Open(profile, r.Name)
+	pc.APIKeys = make(map[string]string, len(r.Auth.APIKeys))
+	for _, k := range r.Auth.APIKeys {
+		pc.APIKeys[k.ID] = k.Hash
+	}
+	if o := r.Auth.OIDC; o != nil {
+		pc.OIDC = &authproxy.OIDCConfig{
+			Issuer:         o.Issuer,
+			ClientID:       o.ClientID,
+			ClientSecret:   o.ClientSecret,
+			AllowedEmails:  o.AllowedEmails,
+			AllowedDomains: o.AllowedDomains,
+		}
+	}
+	return pc, nil
+}
+
+// extractPort 从 "http://localhost:3000" 格式中提取端口号
+func extractPort(service string) string {
+	idx := strings.LastIndex(service, ":")
+	if idx < 0 {
+		return ""
+	}
+	return service[idx+1:]
+}
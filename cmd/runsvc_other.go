@@ -0,0 +1,10 @@
+//go:build !windows
+
+package cmd
+
+import "github.com/qingchencloud/cftunnel/internal/daemon"
+
+// runSupervisor 非 Windows 平台直接前台运行，systemd/launchd 通过信号管理
+func runSupervisor(sv *daemon.Supervisor) error {
+	return sv.Run()
+}
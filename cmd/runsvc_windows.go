@@ -0,0 +1,49 @@
+//go:build windows
+
+package cmd
+
+import (
+	"github.com/qingchencloud/cftunnel/internal/daemon"
+	"golang.org/x/sys/windows/svc"
+)
+
+// runSupervisor 由 SCM 启动时接入 Windows 服务控制，否则直接前台运行
+func runSupervisor(sv *daemon.Supervisor) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return sv.Run()
+	}
+	return svc.Run("cftunnel", &winService{sv: sv})
+}
+
+// winService 将 SCM 的停止请求转换为 Supervisor.Shutdown
+type winService struct {
+	sv *daemon.Supervisor
+}
+
+func (w *winService) Execute(args []string, req <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	done := make(chan error, 1)
+	go func() { done <- w.sv.Run() }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return false, 1
+			}
+			return false, 0
+		case c := <-req:
+			switch c.Cmd {
+			case svc.Interrogate:
+				status <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				w.sv.Shutdown()
+				<-done
+				return false, 0
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "管理配置文件中敏感信息的加密存储",
+	Long:  "加密 config.yml 中的 API Token、隧道 Token、中继 Token、鉴权密码和签名密钥。\n主密钥优先保存在系统密钥环，不可用时使用口令派生（scrypt + AES-GCM）。\n非交互场景可通过 CFTUNNEL_PASSPHRASE 环境变量提供口令。",
+}
+
+func init() {
+	config.PassphrasePrompt = promptPassphrase
+	rootCmd.AddCommand(secretsCmd)
+}
+
+// promptPassphrase 交互式输入口令，设置新口令时需重复确认
+func promptPassphrase(confirm bool) (string, error) {
+	var pass, again string
+	fields := []huh.Field{
+		huh.NewInput().Title("配置口令").EchoMode(huh.EchoModePassword).Value(&pass),
+	}
+	if confirm {
+		fields = append(fields, huh.NewInput().Title("确认口令").EchoMode(huh.EchoModePassword).Value(&again))
+	}
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return "", err
+	}
+	if confirm && pass != again {
+		return "", fmt.Errorf("两次输入的口令不一致")
+	}
+	return pass, nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var secretsMigrateProvider string
+
+func init() {
+	secretsMigrateCmd.Flags().StringVar(&secretsMigrateProvider, "provider", "", "密钥来源 (keyring/passphrase)，默认优先使用系统密钥环")
+	secretsCmd.AddCommand(secretsMigrateCmd)
+}
+
+var secretsMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "将明文配置迁移为加密存储",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider := secretsMigrateProvider
+		err := config.UpdateProfile(config.DefaultProfile, func(cfg *config.Config) error {
+			if cfg.Encrypted() {
+				return fmt.Errorf("配置已加密 (%s)，如需更换密钥请使用 cftunnel secrets rotate", cfg.Secrets.Provider)
+			}
+			if provider == "" {
+				provider = config.SecretsPassphrase
+				if config.KeyringAvailable() {
+					provider = config.SecretsKeyring
+				} else {
+					fmt.Println("系统密钥环不可用，使用口令加密")
+				}
+			}
+			return cfg.EnableEncryption(provider)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 敏感信息已加密存储 (%s): %s\n", provider, config.Path())
+		return nil
+	},
+}
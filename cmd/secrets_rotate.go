@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var secretsRotateProvider string
+
+func init() {
+	secretsRotateCmd.Flags().StringVar(&secretsRotateProvider, "provider", "", "切换密钥来源 (keyring/passphrase)，默认保持不变")
+	secretsCmd.AddCommand(secretsRotateCmd)
+}
+
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "更换主密钥并重新加密（passphrase 模式下即修改口令）",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider := secretsRotateProvider
+		err := config.UpdateProfile(config.DefaultProfile, func(cfg *config.Config) error {
+			if !cfg.Encrypted() {
+				return fmt.Errorf("配置未加密，请先执行 cftunnel secrets migrate")
+			}
+			if provider == "" {
+				provider = cfg.Secrets.Provider
+			}
+			if provider == config.SecretsPassphrase {
+				fmt.Println("请设置新口令")
+			}
+			return cfg.EnableEncryption(provider)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✔ 主密钥已更换 (%s)\n", provider)
+		return nil
+	},
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "管理分享链接（生成见 cftunnel share-link）",
+}
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareLinkTTL     time.Duration
+	shareLinkPath    string
+	shareLinkMaxUses int
+	shareLinkOnce    bool
+)
+
+func init() {
+	shareLinkCmd.Flags().DurationVar(&shareLinkTTL, "ttl", 24*time.Hour, "有效期（如 30m、4h），兑换后的登录状态同样在此时失效")
+	shareLinkCmd.Flags().StringVar(&shareLinkPath, "path", "/", "打开链接后跳转的路径")
+	shareLinkCmd.Flags().IntVar(&shareLinkMaxUses, "max-uses", 0, "最多可兑换次数（0 表示不限）")
+	shareLinkCmd.Flags().BoolVar(&shareLinkOnce, "once", false, "仅可使用一次（等同 --max-uses 1）")
+	rootCmd.AddCommand(shareLinkCmd)
+}
+
+var shareLinkCmd = &cobra.Command{
+	Use:   "share-link <路由>",
+	Short: "生成带有效期的免密访问链接（适合临时分享给客户）",
+	Long: `使用路由的签名密钥生成分享链接，打开后无需密码即获得登录状态，直到链接过期。
+隧道运行中也可直接生成；链接和兑换次数记录在路由的会话文件中。
+吊销链接（同时使已兑换的登录状态失效）: cftunnel share revoke <路由> <链接ID>`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName := args[0]
+		if shareLinkTTL <= 0 {
+			return fmt.Errorf("--ttl 必须大于 0")
+		}
+		if !strings.HasPrefix(shareLinkPath, "/") {
+			return fmt.Errorf("--path 必须以 / 开头")
+		}
+		if shareLinkMaxUses < 0 {
+			return fmt.Errorf("--max-uses 不能为负数")
+		}
+		if shareLinkOnce {
+			shareLinkMaxUses = 1
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		route, err := findAuthRoute(cfg, routeName)
+		if err != nil {
+			return err
+		}
+		if route.Auth == nil {
+			return fmt.Errorf("路由 %s 未启用鉴权，无需分享链接", routeName)
+		}
+		if route.Auth.PortalDomain != "" {
+			return fmt.Errorf("路由 %s 是 SSO 门户，请为接入门户的路由生成分享链接", routeName)
+		}
+		key, err := hex.DecodeString(route.Auth.SigningKey)
+		if err != nil || len(key) == 0 {
+			return fmt.Errorf("路由 %s 的 signing_key 无效", routeName)
+		}
+
+		expires := time.Now().Add(shareLinkTTL)
+		t := authproxy.ShareToken{
+			ID:      session.NewID()[:16],
+			Path:    shareLinkPath,
+			Expires: expires.Unix(),
+			MaxUses: shareLinkMaxUses,
+		}
+		if err := session.Open(cfg.ActiveProfile(), routeName).IssueShare(t.ID, expires); err != nil {
+			return fmt.Errorf("登记分享链接失败: %w", err)
+		}
+		fmt.Println(authproxy.ShareURL(route.Hostname, key, t))
+		uses := "不限次数"
+		if t.MaxUses > 0 {
+			uses = fmt.Sprintf("最多使用 %d 次", t.MaxUses)
+		}
+		fmt.Printf("链接 ID: %s，有效期至 %s，%s\n", t.ID, expires.Format("2006-01-02 15:04"), uses)
+		return nil
+	},
+}
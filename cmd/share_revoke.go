@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	shareCmd.AddCommand(shareRevokeCmd)
+}
+
+var shareRevokeCmd = &cobra.Command{
+	Use:   "revoke <路由> <链接ID>",
+	Short: "吊销分享链接，已兑换的登录状态一并失效，无需重启隧道",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeName, id := args[0], args[1]
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if _, err := findAuthRoute(cfg, routeName); err != nil {
+			return err
+		}
+		removed, found, err := session.Open(cfg.ActiveProfile(), routeName).RevokeShare(id)
+		if err != nil {
+			return fmt.Errorf("吊销分享链接失败: %w", err)
+		}
+		if !found {
+			return fmt.Errorf("路由 %s 中没有有效的分享链接 %s（可能已过期）", routeName, id)
+		}
+		fmt.Printf("✔ 分享链接 %s 已吊销，删除 %d 个已兑换的会话 (%s)\n", id, removed, routeName)
+		return nil
+	},
+}
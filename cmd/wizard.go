@@ -11,6 +11,7 @@ import (
 	"github.com/qingchencloud/cftunnel/internal/cfapi"
 	"github.com/qingchencloud/cftunnel/internal/config"
 	"github.com/qingchencloud/cftunnel/internal/daemon"
+	"github.com/qingchencloud/cftunnel/internal/passwd"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +20,7 @@ var (
 	wizardPort   string
 	wizardAuth   string
 	wizardName   string
+	wizardPublic []string
 )
 
 func init() {
@@ -26,6 +28,7 @@ func init() {
 	wizardCmd.Flags().StringVar(&wizardPort, "port", "", "本地服务端口")
 	wizardCmd.Flags().StringVar(&wizardName, "name", "", "路由名称 (默认使用域名前缀)")
 	wizardCmd.Flags().StringVar(&wizardAuth, "auth", "", "密码保护 (格式: 用户名:密码)")
+	wizardCmd.Flags().StringSliceVar(&wizardPublic, "public-path", nil, "无需鉴权的路径，前缀或通配符（可重复），仅 --auth 时有效")
 	rootCmd.AddCommand(wizardCmd)
 }
 
@@ -84,7 +87,11 @@ func runWizard(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("API Token 和 Account ID 不能同时为空")
 		}
 
-		if err := cfg.Save(); err != nil {
+		err = config.Update(func(latest *config.Config) error {
+			latest.Auth = cfg.Auth
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 		fmt.Println("✓ 认证信息已保存")
@@ -132,7 +139,11 @@ func runWizard(cmd *cobra.Command, args []string) error {
 			Name:  tunnelName,
 			Token: tunnelToken,
 		}
-		if err := cfg.Save(); err != nil {
+		err = config.Update(func(latest *config.Config) error {
+			latest.Tunnel = cfg.Tunnel
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 		fmt.Printf("✓ Tunnel 创建成功: %s\n", tunnelName)
@@ -149,7 +160,9 @@ func runWizard(cmd *cobra.Command, args []string) error {
 	// 启动 tunnel（如果未运行）
 	if !daemon.Running() {
 		fmt.Println("📋 第3步: 启动 Tunnel")
-		go daemon.Start(cfg.Tunnel.Token)
+		if err := daemon.Start(); err != nil {
+			return fmt.Errorf("启动 Tunnel 失败: %w", err)
+		}
 		fmt.Println("✓ Tunnel 已启动")
 		fmt.Println()
 	}
@@ -185,6 +198,14 @@ func runWizard(cmd *cobra.Command, args []string) error {
 	if domain == "" || port == "" {
 		return fmt.Errorf("域名和端口不能为空")
 	}
+	if len(wizardPublic) > 0 {
+		if wizardAuth == "" {
+			return fmt.Errorf("--public-path 需与 --auth 同时使用")
+		}
+		if err := validatePublicPaths(wizardPublic); err != nil {
+			return err
+		}
+	}
 
 	// 生成路由名称
 	if routeName == "" {
@@ -235,17 +256,31 @@ func runWizard(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		hash, err := passwd.Hash(pass)
+		if err != nil {
+			return err
+		}
 		route.Auth = &config.AuthProxy{
-			Username:   user,
-			Password:   pass,
-			SigningKey: hex.EncodeToString(authproxy.RandomKey()),
+			Users:       []config.AuthUser{{Username: user, Password: hash}},
+			SigningKey:  hex.EncodeToString(authproxy.RandomKey()),
+			PublicPaths: wizardPublic,
 		}
 		fmt.Printf("✓ 已启用密码保护: %s\n", wizardAuth)
+		if len(wizardPublic) > 0 {
+			fmt.Printf("✓ 公开路径: %s\n", strings.Join(wizardPublic, ", "))
+		}
 	}
 
-	// 保存路由
-	cfg.Routes = append(cfg.Routes, route)
-	if err := cfg.Save(); err != nil {
+	// 保存路由（锁内重新加载，避免覆盖其他进程的并发修改）
+	err = config.Update(func(latest *config.Config) error {
+		if latest.FindRoute(route.Name) != nil {
+			return fmt.Errorf("路由 %s 已存在", route.Name)
+		}
+		latest.Routes = append(latest.Routes, route)
+		cfg = latest
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
@@ -0,0 +1,56 @@
+package authproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+const accessJWTHeader = "Cf-Access-Jwt-Assertion"
+
+// AccessConfig Cloudflare Access JWT 校验配置
+type AccessConfig struct {
+	TeamDomain string // 团队域名，如 myteam 或 myteam.cloudflareaccess.com
+	AUD        string // Access 应用的 Audience (AUD) 标签
+}
+
+// accessVerifier 校验 Cf-Access-Jwt-Assertion，JWKS 由 RemoteKeySet 缓存并在遇到未知 kid 时刷新
+type accessVerifier struct {
+	verifier *oidc.IDTokenVerifier
+}
+
+func newAccessVerifier(cfg AccessConfig) *accessVerifier {
+	issuer := AccessIssuer(cfg.TeamDomain)
+	keys := oidc.NewRemoteKeySet(context.Background(), issuer+"/cdn-cgi/access/certs")
+	return &accessVerifier{
+		verifier: oidc.NewVerifier(issuer, keys, &oidc.Config{ClientID: cfg.AUD}),
+	}
+}
+
+// verify 校验请求携带的 Access JWT，返回其中的邮箱（服务令牌为空）
+func (a *accessVerifier) verify(r *http.Request) (string, bool) {
+	raw := r.Header.Get(accessJWTHeader)
+	if raw == "" {
+		return "", false
+	}
+	token, err := a.verifier.Verify(r.Context(), raw)
+	if err != nil {
+		return "", false
+	}
+	var claims struct {
+		Email string `json:"email"`
+	}
+	token.Claims(&claims)
+	return claims.Email, true
+}
+
+// AccessIssuer 将团队域名规范化为 https://<team>.cloudflareaccess.com
+func AccessIssuer(team string) string {
+	team = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(team, "https://"), "http://"), "/")
+	if !strings.Contains(team, ".") {
+		team += ".cloudflareaccess.com"
+	}
+	return "https://" + team
+}
@@ -0,0 +1,159 @@
+package authproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 访问日志格式
+const (
+	AccessLogJSON     = "json"     // 每行一个 JSON 对象
+	AccessLogCombined = "combined" // Apache combined，末尾追加耗时（毫秒）和路由名
+)
+
+// AccessLogger 访问日志写入器，可在多个代理间共享
+type AccessLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format string
+}
+
+// NewAccessLogger 创建访问日志写入器，format 为空时使用 JSON
+func NewAccessLogger(w io.Writer, format string) (*AccessLogger, error) {
+	switch format {
+	case "":
+		format = AccessLogJSON
+	case AccessLogJSON, AccessLogCombined:
+	default:
+		return nil, fmt.Errorf("不支持的访问日志格式 %q（可选 json、combined）", format)
+	}
+	return &AccessLogger{w: w, format: format}, nil
+}
+
+// AccessEntry 一条访问记录
+type AccessEntry struct {
+	Time      time.Time `json:"time"`
+	Route     string    `json:"route"`
+	Host      string    `json:"host"`
+	ClientIP  string    `json:"client_ip"`
+	User      string    `json:"user,omitempty"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	LatencyMS float64   `json:"latency_ms"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+func (l *AccessLogger) write(e *AccessEntry) {
+	var line []byte
+	if l.format == AccessLogCombined {
+		user := e.User
+		if user == "" {
+			user = "-"
+		}
+		line = fmt.Appendf(nil, "%s - %s [%s] %s %d %s %s %s %.3f %s\n",
+			e.ClientIP, user, e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+			strconv.Quote(e.Method+" "+e.URI+" "+e.Proto), e.Status, combinedBytes(e.Bytes),
+			strconv.Quote(dash(e.Referer)), strconv.Quote(dash(e.UserAgent)), e.LatencyMS, e.Route)
+	} else {
+		line, _ = json.Marshal(e)
+		line = append(line, '\n')
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(line)
+}
+
+func combinedBytes(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// identityKey 请求上下文中记录已认证用户的键
+type identityKey struct{}
+
+// setUser 记录当前请求的已认证身份，供访问日志使用
+func setUser(r *http.Request, user string) {
+	if id, ok := r.Context().Value(identityKey{}).(*string); ok {
+		*id = user
+	}
+}
+
+// logAccess 处理请求并写入访问日志
+func (p *Proxy) logAccess(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+	var user string
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	next(rec, r.WithContext(context.WithValue(r.Context(), identityKey{}, &user)))
+
+	p.cfg.AccessLog.write(&AccessEntry{
+		Time:      start,
+		Route:     p.cfg.Name,
+		Host:      r.Host,
+		ClientIP:  ClientIP(r),
+		User:      user,
+		Method:    r.Method,
+		URI:       logURI(r),
+		Proto:     r.Proto,
+		Status:    rec.status,
+		Bytes:     rec.bytes,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Referer:   r.Referer(),
+		UserAgent: r.UserAgent(),
+	})
+}
+
+// statusRecorder 记录响应状态码和字节数
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap 供 http.ResponseController 使用（Flush、WebSocket Hijack）
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Flush 支持 SSE 等流式响应
+func (s *statusRecorder) Flush() {
+	http.NewResponseController(s.ResponseWriter).Flush()
+}
+
+// logURI 返回记录到日志的请求地址；cftunnel 自身路径的查询参数含分享令牌、OIDC code/state 等凭据，不写入日志
+func logURI(r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, "/___auth/") && r.URL.RawQuery != "" {
+		return r.URL.Path + "?redacted"
+	}
+	return r.RequestURI
+}
@@ -0,0 +1,177 @@
+package authproxy
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API Key 格式：cft_<id>_<secret>，id 用于查找与吊销，配置中只保存 SHA-256 摘要
+const apiKeyPrefix = "cft_"
+
+// NewAPIKey 生成 API Key，返回 ID、完整密钥（仅展示一次）及其摘要
+func NewAPIKey() (id, key, hash string) {
+	idBytes := make([]byte, 4)
+	rand.Read(idBytes)
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	id = hex.EncodeToString(idBytes)
+	key = apiKeyPrefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return id, key, HashAPIKey(key)
+}
+
+// HashAPIKey 计算 API Key 摘要（密钥为高熵随机值，无需慢哈希）
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyID 从密钥中解析 ID
+func apiKeyID(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	return id, ok && id != ""
+}
+
+// verifyAPIKey 校验 API Key，返回其 ID
+func (p *Proxy) verifyAPIKey(key string) (string, bool) {
+	id, ok := apiKeyID(key)
+	if !ok {
+		return "", false
+	}
+	hash, ok := p.cfg.APIKeys[id]
+	if !ok {
+		return "", false
+	}
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) != 1 {
+		return "", false
+	}
+	return id, true
+}
+
+var (
+	errBadCredentials = errors.New("凭据无效")
+	// 启用两步验证的用户无法通过 Basic 提交验证码，需使用登录页或 API Key
+	errBasicTOTP = errors.New("Basic 认证不支持启用两步验证的用户，请使用登录页或 API Key")
+)
+
+// credentials 校验 Authorization 头中的 Basic 或 Bearer 凭据，返回身份标识
+// present 表示请求携带了本代理支持的凭据（无论是否有效），err 非空表示凭据被拒绝
+func (p *Proxy) credentials(r *http.Request) (identity string, present bool, err error) {
+	h := r.Header.Get("Authorization")
+	scheme, value, _ := strings.Cut(h, " ")
+	value = strings.TrimSpace(value)
+
+	switch {
+	case strings.EqualFold(scheme, "Bearer") && strings.HasPrefix(value, apiKeyPrefix):
+		id, ok := p.verifyAPIKey(value)
+		if !ok {
+			p.cfg.AuthLog.Printf("API Key 无效 route=%s ip=%s", p.cfg.Name, ClientIP(r))
+			return "", true, errBadCredentials
+		}
+		return "key:" + id, true, nil
+
+	case strings.EqualFold(scheme, "Basic") && p.oidc == nil:
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			return "", false, nil
+		}
+		// 脚本每个请求都携带 Basic 凭据，近期校验通过的凭据不再重复慢哈希，也不占用限速额度
+		hash, known := p.lookupUser(username)
+		if known && p.basic.hit(username, hash, password) && !p.requiresTOTP(username) {
+			return username, true, nil
+		}
+		ip := ClientIP(r)
+		if p.limiter.acquire(ip) > 0 {
+			return "", true, errBadCredentials
+		}
+		if !p.verifyUser(username, password) {
+			p.loginFailed(ip, username)
+			return "", true, errBadCredentials
+		}
+		p.limiter.reset(ip)
+		if p.requiresTOTP(username) {
+			p.cfg.AuthLog.Printf("Basic 认证不支持启用两步验证的用户 route=%s ip=%s user=%q", p.cfg.Name, ip, username)
+			return "", true, errBasicTOTP
+		}
+		p.basic.add(username, hash, password)
+		return username, true, nil
+	}
+	return "", false, nil
+}
+
+// basicCacheTTL Basic 凭据校验结果的缓存时间
+const basicCacheTTL = 5 * time.Minute
+
+// basicCache 缓存校验通过的 Basic 凭据。键为用户名、存储的哈希和密码的 SHA-256 摘要，
+// 不保存明文；密码修改后存储的哈希随之变化，旧缓存自然失效
+type basicCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]time.Time
+}
+
+func newBasicCache() *basicCache {
+	return &basicCache{entries: make(map[[sha256.Size]byte]time.Time)}
+}
+
+func basicCacheKey(username, hash, password string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(username + "\x00" + hash + "\x00" + password))
+}
+
+func (c *basicCache) hit(username, hash, password string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := basicCacheKey(username, hash, password)
+	expires, ok := c.entries[key]
+	if ok && time.Now().After(expires) {
+		delete(c.entries, key)
+		return false
+	}
+	return ok
+}
+
+func (c *basicCache) add(username, hash, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// 清理过期记录；仍然过多时不再缓存，回退为每次校验
+	if len(c.entries) >= 1024 {
+		for k, exp := range c.entries {
+			if now.After(exp) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= 1024 {
+			return
+		}
+	}
+	c.entries[basicCacheKey(username, hash, password)] = now.Add(basicCacheTTL)
+}
+
+// wantsHTML 判断请求是否来自浏览器（接受 HTML 响应）
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// unauthorized 向非浏览器客户端返回 401 及 WWW-Authenticate 质询
+func (p *Proxy) unauthorized(w http.ResponseWriter) {
+	realm := p.cfg.Name
+	if realm == "" {
+		realm = "cftunnel"
+	}
+	if p.oidc == nil {
+		w.Header().Add("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+	}
+	w.Header().Add("WWW-Authenticate", `Bearer realm="`+realm+`"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
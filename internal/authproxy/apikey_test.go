@@ -0,0 +1,95 @@
+package authproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pwHash 密码 "pw" 的 bcrypt 哈希（cost 4，加快测试）
+const pwHash = "$2a$04$dOi1lg1vNiZEbN.839TvnOkzHj36QQtLTCcAQO2TI5skXpU71UlBm"
+
+func TestCredentials(t *testing.T) {
+	up := newUpstream(t)
+	id, key, hash := NewAPIKey()
+
+	tests := []struct {
+		name         string
+		auth         func(r *http.Request)
+		wantCode     int
+		wantUser     string
+		wantFailures int // 限速器记录的失败次数
+	}{
+		{"API Key 有效", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+key) }, http.StatusOK, "key:" + id, 0},
+		{"API Key 篡改", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+key+"x") }, http.StatusUnauthorized, "", 0},
+		{"API Key 未知 ID", func(r *http.Request) { r.Header.Set("Authorization", "Bearer cft_00000000_abc") }, http.StatusUnauthorized, "", 0},
+		{"Basic 正确", func(r *http.Request) { r.SetBasicAuth("alice", "pw") }, http.StatusOK, "alice", 0},
+		{"Basic 密码错误", func(r *http.Request) { r.SetBasicAuth("alice", "wrong") }, http.StatusUnauthorized, "", 1},
+		{"Basic 用户不存在", func(r *http.Request) { r.SetBasicAuth("mallory", "pw") }, http.StatusUnauthorized, "", 1},
+		{"Basic 两步验证用户", func(r *http.Request) { r.SetBasicAuth("bob", "pw") }, http.StatusForbidden, "", 0},
+		{"Basic 两步验证用户密码错误", func(r *http.Request) { r.SetBasicAuth("bob", "wrong") }, http.StatusUnauthorized, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProxy(t, Config{
+				Name: "app", TargetPort: up.port(t), SigningKey: []byte("key"),
+				Users:   map[string]string{"alice": pwHash, "bob": pwHash},
+				TOTP:    map[string]string{"bob": "JBSWY3DPEHPK3PXP"},
+				APIKeys: map[string]string{id: hash},
+			})
+			up.user = ""
+			r := httptest.NewRequest("GET", "/", nil)
+			tt.auth(r)
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if up.user != tt.wantUser {
+				t.Errorf("后端收到用户 %q, want %q", up.user, tt.wantUser)
+			}
+			if tt.wantCode == http.StatusForbidden && !strings.Contains(w.Body.String(), "两步验证") {
+				t.Errorf("响应 %q 未说明 Basic 不支持两步验证", w.Body.String())
+			}
+			if got, _ := p.limiter.fail("192.0.2.1"); got != tt.wantFailures {
+				t.Errorf("失败次数 %d, want %d", got, tt.wantFailures)
+			}
+		})
+	}
+}
+
+// 校验通过的 Basic 凭据缓存后不再占用限速额度；密码修改后缓存失效
+func TestBasicCache(t *testing.T) {
+	up := newUpstream(t)
+	p := newTestProxy(t, Config{
+		Name: "app", TargetPort: up.port(t), SigningKey: []byte("key"),
+		Users: map[string]string{"alice": pwHash},
+	})
+	basic := func(password string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.SetBasicAuth("alice", password)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, r)
+		return w.Code
+	}
+	if code := basic("pw"); code != http.StatusOK {
+		t.Fatalf("首次校验 status = %d, want 200", code)
+	}
+
+	// 同一 IP 被锁定后，已缓存的凭据仍可访问，错误密码仍被拒绝
+	p.limiter.clients["192.0.2.1"] = &attempt{failures: lockoutAfter, next: time.Now().Add(lockoutDuration), last: time.Now()}
+	if code := basic("pw"); code != http.StatusOK {
+		t.Errorf("缓存命中 status = %d, want 200", code)
+	}
+	if code := basic("wrong"); code != http.StatusUnauthorized {
+		t.Errorf("错误密码 status = %d, want 401", code)
+	}
+
+	// 存储的哈希变化（密码已修改）后旧凭据不再命中缓存
+	p.cfg.Users["alice"] = "$2a$04$" + strings.Repeat("a", 53)
+	if code := basic("pw"); code != http.StatusUnauthorized {
+		t.Errorf("密码修改后 status = %d, want 401", code)
+	}
+}
@@ -0,0 +1,131 @@
+package authproxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// 默认的上游身份头，与 Grafana、Gitea 等应用的 auth proxy 模式约定一致
+const (
+	DefaultUserHeader   = "X-Forwarded-User"
+	DefaultEmailHeader  = "X-Forwarded-Email"
+	DefaultGroupsHeader = "X-Forwarded-Groups"
+	SignatureHeader     = "X-Forwarded-Auth-Signature"
+)
+
+// IdentityHeaders 上游身份头配置，名称为空时使用默认值
+type IdentityHeaders struct {
+	User   string
+	Email  string
+	Groups string
+	Secret []byte // 设置后附加签名头：t=<Unix 秒>,v1=<HMAC-SHA256>，见 SignIdentity
+}
+
+// reservedHeaders 不能用作身份头的名称：凭据、路由以及逐跳头，覆盖后会破坏鉴权或转发
+var reservedHeaders = map[string]bool{
+	"Cookie": true, "Authorization": true, "Proxy-Authorization": true, "Host": true,
+	"Connection": true, "Keep-Alive": true, "Proxy-Connection": true, "Te": true, "Trailer": true,
+	"Transfer-Encoding": true, "Upgrade": true, "Content-Length": true,
+	"Cf-Connecting-Ip": true, "Cf-Access-Jwt-Assertion": true, SignatureHeader: true,
+}
+
+// ValidateHeaderName 校验身份头名称：须为合法的 HTTP 头名称，且不能覆盖凭据、路由或逐跳头
+func ValidateHeaderName(name string) error {
+	if !httpguts.ValidHeaderFieldName(name) {
+		return fmt.Errorf("无效的头名称: %q", name)
+	}
+	if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+		return fmt.Errorf("不能使用 %s 作为身份头", name)
+	}
+	return nil
+}
+
+// withDefaults 补全默认头名称
+func (h IdentityHeaders) withDefaults() IdentityHeaders {
+	if h.User == "" {
+		h.User = DefaultUserHeader
+	}
+	if h.Email == "" {
+		h.Email = DefaultEmailHeader
+	}
+	if h.Groups == "" {
+		h.Groups = DefaultGroupsHeader
+	}
+	return h
+}
+
+// identity 已认证的身份
+type identity struct {
+	User   string
+	Email  string
+	Groups []string
+}
+
+// SignIdentity 计算身份头签名，上游用共享密钥按相同方式校验：
+// HMAC-SHA256(secret, "<t>\n<user>\n<email>\n<groups>")，groups 为逗号分隔的头部原值
+func SignIdentity(secret []byte, t int64, user, email, groups string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(t, 10) + "\n" + user + "\n" + email + "\n" + groups))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// userIdentity 根据用户名组装身份：邮箱形式的用户名（OIDC、Access）同时作为 email，组来自配置
+func (p *Proxy) userIdentity(user string) identity {
+	id := identity{User: user, Groups: p.cfg.Groups[user]}
+	if strings.Contains(user, "@") {
+		id.Email = user
+	}
+	return id
+}
+
+// proxy 清除客户端伪造的身份头，写入已认证身份后转发到后端；id.User 为空时仅清除
+func (p *Proxy) proxy(w http.ResponseWriter, r *http.Request, id identity) {
+	h := p.headers
+	// 自定义头名称时同样清除默认头，避免后端仍读取默认头时被伪造
+	for _, name := range []string{h.User, h.Email, h.Groups, SignatureHeader, DefaultUserHeader, DefaultEmailHeader, DefaultGroupsHeader} {
+		r.Header.Del(name)
+	}
+	stripCookies(r)
+	if id.User != "" {
+		groups := strings.Join(id.Groups, ",")
+		r.Header.Set(h.User, id.User)
+		if id.Email != "" {
+			r.Header.Set(h.Email, id.Email)
+		}
+		if groups != "" {
+			r.Header.Set(h.Groups, groups)
+		}
+		if len(h.Secret) > 0 {
+			t := time.Now().Unix()
+			r.Header.Set(SignatureHeader, "t="+strconv.FormatInt(t, 10)+",v1="+SignIdentity(h.Secret, t, id.User, id.Email, groups))
+		}
+	}
+	p.reverse.ServeHTTP(w, r)
+}
+
+// stripCookies 删除 cftunnel 自身的 Cookie（登录、SSO、CSRF 等），后端无法读取后重放到其他路由
+func stripCookies(r *http.Request) {
+	var kept []string
+	for _, line := range r.Header.Values("Cookie") {
+		for _, part := range strings.Split(line, ";") {
+			part = strings.TrimSpace(part)
+			if part == "" || strings.HasPrefix(part, cookiePrefix) {
+				continue
+			}
+			kept = append(kept, part)
+		}
+	}
+	r.Header.Del("Cookie")
+	if len(kept) > 0 {
+		r.Header.Set("Cookie", strings.Join(kept, "; "))
+	}
+}
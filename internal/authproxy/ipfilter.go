@@ -0,0 +1,139 @@
+package authproxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// IPFilterConfig 按客户端 IP / 国家过滤请求，deny 优先于 allow；allow 非空时仅放行匹配项
+type IPFilterConfig struct {
+	Allow          []string // CIDR 或单个 IP
+	Deny           []string
+	AllowCountries []string // ISO 3166 国家代码（CF-IPCountry），如 CN、US
+	DenyCountries  []string
+}
+
+// ipFilter 解析后的过滤规则
+type ipFilter struct {
+	allow, deny                   []netip.Prefix
+	allowCountries, denyCountries map[string]bool
+}
+
+// ParsePrefix 解析 CIDR，单个 IP 视为 /32（IPv6 为 /128）
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("无效的 CIDR %q", s)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("无效的 IP %q", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func newIPFilter(cfg IPFilterConfig) (*ipFilter, error) {
+	f := &ipFilter{}
+	for _, s := range cfg.Allow {
+		p, err := ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		f.allow = append(f.allow, p)
+	}
+	for _, s := range cfg.Deny {
+		p, err := ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		f.deny = append(f.deny, p)
+	}
+	f.allowCountries = countrySet(cfg.AllowCountries)
+	f.denyCountries = countrySet(cfg.DenyCountries)
+	return f, nil
+}
+
+func countrySet(codes []string) map[string]bool {
+	if len(codes) == 0 {
+		return nil
+	}
+	m := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		m[strings.ToUpper(strings.TrimSpace(c))] = true
+	}
+	return m
+}
+
+// permit 判断请求是否放行；无法确定 IP 或国家时按规则拒绝（allow 非空即失败关闭）
+func (f *ipFilter) permit(r *http.Request) bool {
+	if len(f.allow) > 0 || len(f.deny) > 0 {
+		addr, err := netip.ParseAddr(ClientIP(r))
+		if err != nil {
+			return false
+		}
+		addr = addr.Unmap()
+		if containsAddr(f.deny, addr) {
+			return false
+		}
+		if len(f.allow) > 0 && !containsAddr(f.allow, addr) {
+			return false
+		}
+	}
+
+	// 国家信息仅在经 cloudflared 转发时可信
+	country := ""
+	if viaCloudflared(r) {
+		country = strings.ToUpper(r.Header.Get("CF-IPCountry"))
+	}
+	if f.denyCountries[country] {
+		return false
+	}
+	if f.allowCountries != nil && !f.allowCountries[country] {
+		return false
+	}
+	return true
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// viaCloudflared 请求是否经由本机 cloudflared 转发：连接来自回环地址且携带 CF-Connecting-IP
+// 代理仅监听 127.0.0.1，外部流量只能经由 cloudflared 到达；其他连接不信任 CF-* 头
+func viaCloudflared(r *http.Request) bool {
+	if r.Header.Get("CF-Connecting-IP") == "" {
+		return false
+	}
+	addr, err := netip.ParseAddr(peerIP(r))
+	return err == nil && addr.Unmap().IsLoopback()
+}
+
+// peerIP 返回连接的对端地址
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ClientIP 返回客户端 IP：经 cloudflared 转发时取 CF-Connecting-IP，否则使用连接地址
+func ClientIP(r *http.Request) string {
+	if viaCloudflared(r) {
+		return strings.TrimSpace(r.Header.Get("CF-Connecting-IP"))
+	}
+	return peerIP(r)
+}
@@ -0,0 +1,177 @@
+package authproxy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const csrfCookieName = "__cftunnel_csrf"
+
+// LoginPage 登录页模板数据，自定义模板（html/template）可使用以下字段
+type LoginPage struct {
+	Lang    string    // 页面语言：zh-CN 或 en
+	Route   string    // 路由名称
+	Title   string    // 标题，未配置时为默认文字
+	Message string    // 自定义说明，可为空
+	Error   string    // 登录失败原因，可为空
+	Action  string    // 表单提交地址
+	CSRF    string    // 表单需以隐藏字段 csrf 原样提交
+	TOTP    bool      // 是否有用户启用两步验证（需显示 totp 输入框）
+	T       LoginText // 当前语言的界面文字
+}
+
+// LoginText 登录页界面文字
+type LoginText struct {
+	Title    string
+	Username string
+	Password string
+	TOTP     string
+	Submit   string
+	Invalid  string // 用户名、密码或验证码错误
+	Expired  string // CSRF 校验失败
+	Locked   string // 登录失败过多，%d 为等待秒数
+}
+
+// loginTexts 内置语言，按 Accept-Language 选择，无匹配时使用中文
+var loginTexts = map[string]LoginText{
+	"zh": {
+		Title:    "此服务需要身份验证",
+		Username: "用户名",
+		Password: "密码",
+		TOTP:     "两步验证码（未启用可留空）",
+		Submit:   "登 录",
+		Invalid:  "用户名、密码或验证码错误",
+		Expired:  "页面已过期，请重新提交",
+		Locked:   "登录失败次数过多，请 %d 秒后重试",
+	},
+	"en": {
+		Title:    "Authentication required",
+		Username: "Username",
+		Password: "Password",
+		TOTP:     "Verification code (leave blank if not enabled)",
+		Submit:   "Sign in",
+		Invalid:  "Invalid username, password or verification code",
+		Expired:  "This page has expired, please try again",
+		Locked:   "Too many failed attempts, please retry in %d seconds",
+	},
+}
+
+var langTags = map[string]string{"zh": "zh-CN", "en": "en"}
+
+// defaultLoginTemplate 内置登录页
+var defaultLoginTemplate = template.Must(template.New("login").Parse(string(loginHTML)))
+
+// parseLoginTemplate 解析自定义登录页模板，path 为空时使用内置模板
+func parseLoginTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return defaultLoginTemplate, nil
+	}
+	t, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("解析登录页模板失败: %w", err)
+	}
+	return t, nil
+}
+
+// ValidateLoginTemplate 解析模板并用示例数据试渲染，提前发现语法或字段错误
+func ValidateLoginTemplate(path string) error {
+	t, err := parseLoginTemplate(path)
+	if err != nil {
+		return err
+	}
+	page := LoginPage{Lang: "zh-CN", Route: "demo", Error: "示例", Action: loginPath, CSRF: "-", TOTP: true, T: loginTexts["zh"]}
+	page.Title = page.T.Title
+	if err := t.Execute(io.Discard, page); err != nil {
+		return fmt.Errorf("渲染登录页模板失败: %w", err)
+	}
+	return nil
+}
+
+// pickLang 按 Accept-Language 的权重选择内置语言
+func pickLang(header string) string {
+	best, bestQ := "zh", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := loginTexts[primary]; ok && q > bestQ {
+			best, bestQ = primary, q
+		}
+	}
+	return best
+}
+
+// renderLogin 渲染登录页，errMsg 非空时根据当前语言生成错误提示
+func (p *Proxy) renderLogin(w http.ResponseWriter, r *http.Request, status int, errMsg func(LoginText) string) {
+	lang := pickLang(r.Header.Get("Accept-Language"))
+	page := LoginPage{
+		Lang:    langTags[lang],
+		Route:   p.cfg.Name,
+		Title:   p.cfg.LoginTitle,
+		Message: p.cfg.LoginMessage,
+		Action:  loginPath,
+		CSRF:    p.csrfToken(w, r),
+		TOTP:    len(p.cfg.TOTP) > 0,
+		T:       loginTexts[lang],
+	}
+	if page.Title == "" {
+		page.Title = page.T.Title
+	}
+	if errMsg != nil {
+		page.Error = errMsg(page.T)
+	}
+	// 门户登录后需跳回来源地址
+	if rd := p.portalReturn(r); rd != "" {
+		page.Action += "?rd=" + url.QueryEscape(rd)
+	}
+
+	var buf bytes.Buffer
+	if err := p.loginPage.Execute(&buf, page); err != nil {
+		p.cfg.AuthLog.Printf("渲染登录页失败 route=%s: %v", p.cfg.Name, err)
+		http.Error(w, "登录页模板错误", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// csrfToken 返回表单 CSRF 令牌：Cookie 中保存随机值，表单提交其 HMAC，子域名写入的 Cookie 无法伪造
+func (p *Proxy) csrfToken(w http.ResponseWriter, r *http.Request) string {
+	nonce := ""
+	if c, err := r.Cookie(csrfCookieName); err == nil && len(c.Value) == 32 {
+		nonce = c.Value
+	} else {
+		nonce = randomToken()
+		http.SetCookie(w, &http.Cookie{
+			Name:     csrfCookieName,
+			Value:    nonce,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return signPayload(p.cfg.SigningKey, "csrf:"+nonce)
+}
+
+// verifyCSRF 校验登录表单的 CSRF 令牌
+func (p *Proxy) verifyCSRF(r *http.Request) bool {
+	c, err := r.Cookie(csrfCookieName)
+	if err != nil || c.Value == "" {
+		return false
+	}
+	want := signPayload(p.cfg.SigningKey, "csrf:"+c.Value)
+	return hmac.Equal([]byte(want), []byte(r.PostFormValue("csrf")))
+}
@@ -0,0 +1,258 @@
+package authproxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const callbackPath = "/___auth/callback"
+const oidcCookieName = "__cftunnel_oidc"
+
+// oidcSignPrefix 授权流程 Cookie 的签名域前缀，与鉴权 Cookie 区分，防止互相替换
+const oidcSignPrefix = "oidc:"
+
+// oidcFlowTTL 授权流程（跳转 IdP 到回调）的最长时间
+const oidcFlowTTL = 10 * time.Minute
+
+// OIDCConfig OpenID Connect 登录配置
+type OIDCConfig struct {
+	Issuer         string
+	ClientID       string
+	ClientSecret   string   // 公共客户端可为空（仅 PKCE）
+	AllowedEmails  []string // 允许的邮箱，与 AllowedDomains 均为空时拒绝所有用户
+	AllowedDomains []string // 允许的邮箱域名
+}
+
+// oidcFlow 授权流程状态，签名后暂存在 Cookie 中
+type oidcFlow struct {
+	State    string `json:"s"`
+	Verifier string `json:"v"`
+	Nonce    string `json:"n"`
+	Return   string `json:"r"`
+	Expiry   int64  `json:"e"`
+}
+
+// oidcProvider 延迟初始化的 OIDC Provider，IdP 暂时不可达时下次请求重试发现
+type oidcProvider struct {
+	cfg      OIDCConfig
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func (o *oidcProvider) get(ctx context.Context) (*oidc.Provider, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+	p, err := oidc.NewProvider(ctx, o.cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	o.provider = p
+	return p, nil
+}
+
+// allowed 检查邮箱是否在允许列表中（不区分大小写）
+func (o *oidcProvider) allowed(email string) bool {
+	email = strings.ToLower(email)
+	for _, e := range o.cfg.AllowedEmails {
+		if strings.ToLower(e) == email {
+			return true
+		}
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	for _, d := range o.cfg.AllowedDomains {
+		if strings.ToLower(strings.TrimPrefix(d, "@")) == email[at+1:] {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Proxy) oauth2Config(provider *oidc.Provider, r *http.Request) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.oidc.cfg.ClientID,
+		ClientSecret: p.oidc.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  requestOrigin(r) + callbackPath,
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+}
+
+// startOIDC 生成 state / PKCE verifier / nonce，跳转到 IdP 授权页
+func (p *Proxy) startOIDC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+	provider, err := p.oidc.get(r.Context())
+	if err != nil {
+		p.cfg.AuthLog.Printf("OIDC 发现失败 route=%s issuer=%s: %v", p.cfg.Name, p.oidc.cfg.Issuer, err)
+		http.Error(w, "身份提供商暂不可用，请稍后重试", http.StatusBadGateway)
+		return
+	}
+
+	flow := oidcFlow{
+		State:    randomToken(),
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    randomToken(),
+		Return:   r.URL.RequestURI(),
+		Expiry:   time.Now().Add(oidcFlowTTL).Unix(),
+	}
+	data, _ := json.Marshal(flow)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    payload + "." + signPayload(p.cfg.SigningKey, oidcSignPrefix+payload),
+		Path:     callbackPath,
+		MaxAge:   int(oidcFlowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode, // IdP 回跳为顶级 GET 导航，Lax 即可携带
+	})
+
+	url := p.oauth2Config(provider, r).AuthCodeURL(flow.State,
+		oauth2.S256ChallengeOption(flow.Verifier), oidc.Nonce(flow.Nonce))
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// handleCallback 校验 state，用授权码 + PKCE verifier 换取 ID Token 并校验，签发鉴权 Cookie
+func (p *Proxy) handleCallback(w http.ResponseWriter, r *http.Request) {
+	flow, ok := p.readFlow(r)
+	if !ok || r.URL.Query().Get("state") != flow.State {
+		p.oidcFailed(r, "state 无效或已过期")
+		http.Error(w, "登录状态无效或已过期，请返回重新访问", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcCookieName, Path: callbackPath, MaxAge: -1})
+	if e := r.URL.Query().Get("error"); e != "" {
+		p.oidcFailed(r, fmt.Sprintf("身份提供商返回错误 error=%q description=%q", e, r.URL.Query().Get("error_description")))
+		http.Error(w, "身份提供商拒绝了登录: "+e, http.StatusForbidden)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	provider, err := p.oidc.get(ctx)
+	if err != nil {
+		p.oidcFailed(r, fmt.Sprintf("发现失败 issuer=%s: %v", p.oidc.cfg.Issuer, err))
+		http.Error(w, "身份提供商暂不可用，请稍后重试", http.StatusBadGateway)
+		return
+	}
+	token, err := p.oauth2Config(provider, r).Exchange(ctx, r.URL.Query().Get("code"), oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		p.oidcFailed(r, fmt.Sprintf("授权码兑换失败: %v", err))
+		http.Error(w, "登录失败：授权码兑换失败", http.StatusForbidden)
+		return
+	}
+	rawID, _ := token.Extra("id_token").(string)
+	if rawID == "" {
+		p.oidcFailed(r, "未返回 ID Token")
+		http.Error(w, "登录失败：身份提供商未返回 ID Token", http.StatusForbidden)
+		return
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.oidc.cfg.ClientID}).Verify(ctx, rawID)
+	if err != nil {
+		p.oidcFailed(r, fmt.Sprintf("ID Token 校验失败: %v", err))
+		http.Error(w, "登录失败：ID Token 无效", http.StatusForbidden)
+		return
+	}
+	if idToken.Nonce != flow.Nonce {
+		p.oidcFailed(r, "nonce 不匹配")
+		http.Error(w, "登录失败：nonce 不匹配", http.StatusForbidden)
+		return
+	}
+
+	var claims struct {
+		Email         string   `json:"email"`
+		EmailVerified *bool    `json:"email_verified"`
+		Groups        []string `json:"groups"`
+	}
+	if err := idToken.Claims(&claims); err != nil || claims.Email == "" {
+		p.oidcFailed(r, fmt.Sprintf("ID Token 中缺少 email sub=%s", idToken.Subject))
+		http.Error(w, "登录失败：ID Token 中缺少 email", http.StatusForbidden)
+		return
+	}
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		p.oidcFailed(r, fmt.Sprintf("邮箱未验证 email=%s", claims.Email))
+		http.Error(w, "登录失败：邮箱未验证", http.StatusForbidden)
+		return
+	}
+	if !p.oidc.allowed(claims.Email) {
+		p.oidcFailed(r, fmt.Sprintf("邮箱不在允许列表 email=%s", claims.Email))
+		http.Error(w, fmt.Sprintf("%s 无权访问", claims.Email), http.StatusForbidden)
+		return
+	}
+
+	setUser(r, strings.ToLower(claims.Email))
+	if err := p.issueCookie(w, r, strings.ToLower(claims.Email), claims.Groups); err != nil {
+		p.cfg.AuthLog.Printf("登记会话失败 route=%s: %v", p.cfg.Name, err)
+		http.Error(w, "登录失败，请稍后重试", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, safeReturn(flow.Return), http.StatusSeeOther)
+}
+
+// oidcFailed 记录 OIDC 回调被拒绝的原因
+func (p *Proxy) oidcFailed(r *http.Request, reason string) {
+	p.cfg.AuthLog.Printf("OIDC 登录失败 route=%s ip=%s: %s", p.cfg.Name, ClientIP(r), reason)
+}
+
+// readFlow 读取并校验授权流程 Cookie
+func (p *Proxy) readFlow(r *http.Request) (*oidcFlow, bool) {
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return nil, false
+	}
+	payload, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !verifySignature(p.cfg.SigningKey, oidcSignPrefix+payload, sig) {
+		return nil, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, false
+	}
+	var flow oidcFlow
+	if json.Unmarshal(data, &flow) != nil || time.Now().Unix() >= flow.Expiry {
+		return nil, false
+	}
+	return &flow, true
+}
+
+// requestOrigin 还原外部访问地址（cloudflared 转发时携带 X-Forwarded-Proto）
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// safeReturn 只允许站内相对路径，防止开放重定向
+func safeReturn(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,198 @@
+package authproxy
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIdP 最小的 OIDC 身份提供商：发现文档、JWKS 和令牌端点，ID Token 以 RS256 手工签名
+type fakeIdP struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any // 令牌端点返回的 ID Token 声明
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "alg": "RS256", "use": "sig", "kid": "test",
+			"n": b64(key.N.Bytes()),
+			"e": b64(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, idp.claims),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "test"})
+	body, _ := json.Marshal(claims)
+	signing := b64(header) + "." + b64(body)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing + "." + b64(sig)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestOIDCCallback(t *testing.T) {
+	idp := newFakeIdP(t)
+	up := newUpstream(t)
+
+	tests := []struct {
+		name     string
+		query    func(state string) string // 回调查询参数
+		claims   func(nonce string) map[string]any
+		wantCode int
+		wantLog  string // 鉴权日志应包含的原因，为空表示登录成功
+	}{
+		{
+			name: "登录成功",
+			claims: func(nonce string) map[string]any {
+				return map[string]any{"nonce": nonce, "email": "Alice@Example.com", "email_verified": true}
+			},
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "state 不匹配",
+			query:    func(string) string { return "state=forged&code=c" },
+			wantCode: http.StatusBadRequest,
+			wantLog:  "state 无效",
+		},
+		{
+			name:     "身份提供商返回错误",
+			query:    func(state string) string { return "state=" + state + "&error=access_denied" },
+			wantCode: http.StatusForbidden,
+			wantLog:  `error="access_denied"`,
+		},
+		{
+			name:     "nonce 不匹配",
+			claims:   func(string) map[string]any { return map[string]any{"nonce": "other", "email": "alice@example.com"} },
+			wantCode: http.StatusForbidden,
+			wantLog:  "nonce 不匹配",
+		},
+		{
+			name:     "缺少 email",
+			claims:   func(nonce string) map[string]any { return map[string]any{"nonce": nonce} },
+			wantCode: http.StatusForbidden,
+			wantLog:  "缺少 email sub=alice",
+		},
+		{
+			name: "邮箱未验证",
+			claims: func(nonce string) map[string]any {
+				return map[string]any{"nonce": nonce, "email": "alice@example.com", "email_verified": false}
+			},
+			wantCode: http.StatusForbidden,
+			wantLog:  "邮箱未验证 email=alice@example.com",
+		},
+		{
+			name:     "邮箱不在允许列表",
+			claims:   func(nonce string) map[string]any { return map[string]any{"nonce": nonce, "email": "mallory@evil.com"} },
+			wantCode: http.StatusForbidden,
+			wantLog:  "邮箱不在允许列表 email=mallory@evil.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProxy(t, Config{
+				Name: "app", Hostname: "app.example.com", TargetPort: up.port(t), SigningKey: []byte("oidc-key"),
+				OIDC: &OIDCConfig{Issuer: idp.URL, ClientID: "client", AllowedEmails: []string{"alice@example.com"}},
+			})
+			var authLog bytes.Buffer
+			p.cfg.AuthLog = log.New(&authLog, "", 0)
+
+			// 未登录访问跳转 IdP，取出 state、nonce 和授权流程 Cookie
+			r := httptest.NewRequest("GET", "https://app.example.com/docs", nil)
+			r.Header.Set("Accept", "text/html")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, r)
+			if w.Code != http.StatusFound {
+				t.Fatalf("未登录访问 status = %d, want 302", w.Code)
+			}
+			authURL, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, nonce := authURL.Query().Get("state"), authURL.Query().Get("nonce")
+			flow := w.Result().Cookies()[0]
+
+			claims := map[string]any{"nonce": nonce, "email": "alice@example.com"}
+			if tt.claims != nil {
+				claims = tt.claims(nonce)
+			}
+			claims["iss"], claims["aud"], claims["sub"] = idp.URL, "client", "alice"
+			claims["iat"], claims["exp"] = time.Now().Unix(), time.Now().Add(time.Hour).Unix()
+			idp.claims = claims
+
+			query := "state=" + state + "&code=c"
+			if tt.query != nil {
+				query = tt.query(state)
+			}
+			r = httptest.NewRequest("GET", "https://app.example.com"+callbackPath+"?"+query, nil)
+			r.AddCookie(flow)
+			w = httptest.NewRecorder()
+			p.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("回调 status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+
+			got := authLog.String()
+			if tt.wantLog == "" {
+				if loc := w.Header().Get("Location"); loc != "/docs" {
+					t.Errorf("登录后跳转 %q, want /docs", loc)
+				}
+				if got != "" {
+					t.Errorf("登录成功不应记录失败日志: %s", got)
+				}
+				return
+			}
+			if !strings.Contains(got, "OIDC 登录失败 route=app ip=192.0.2.1") || !strings.Contains(got, tt.wantLog) {
+				t.Errorf("鉴权日志 %q, want 包含客户端 IP 和 %q", got, tt.wantLog)
+			}
+		})
+	}
+}
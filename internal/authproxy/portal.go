@@ -0,0 +1,78 @@
+package authproxy
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/qingchencloud/cftunnel/internal/session"
+)
+
+// ssoCookieName 门户签发的父域名 Cookie，与路由自身的 Cookie 区分，避免同名冲突
+const ssoCookieName = "__cftunnel_sso"
+
+// InDomain 判断 host 是否为 domain 本身或其子域名
+func InDomain(host, domain string) bool {
+	host, domain = strings.ToLower(host), strings.ToLower(strings.TrimPrefix(domain, "."))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// isPortal 是否作为 SSO 门户运行
+func (p *Proxy) isPortal() bool {
+	return p.cfg.PortalDomain != ""
+}
+
+// cookieName 返回本代理签发的 Cookie 名称
+func (p *Proxy) cookieName() string {
+	if p.isPortal() {
+		return ssoCookieName
+	}
+	return cookieName
+}
+
+// portalReturn 返回 rd 参数中的跳转地址，仅门户允许且限父域名下的 https 地址，防止开放重定向
+func (p *Proxy) portalReturn(r *http.Request) string {
+	rd := r.URL.Query().Get("rd")
+	u, err := url.Parse(rd)
+	if rd == "" || err != nil || u.Scheme != "https" || u.User != nil || !InDomain(u.Hostname(), p.cfg.PortalDomain) {
+		return ""
+	}
+	return rd
+}
+
+// servePortal 门户请求：已登录时跳回 rd 指定的地址，否则显示登录页（门户没有后端服务）
+func (p *Proxy) servePortal(w http.ResponseWriter, r *http.Request) {
+	user, ok := p.checkAuth(r)
+	if !ok {
+		if p.oidc != nil {
+			p.startOIDC(w, r)
+			return
+		}
+		p.renderLogin(w, r, http.StatusOK, nil)
+		return
+	}
+	setUser(r, user)
+	if rd := p.portalReturn(r); rd != "" {
+		http.Redirect(w, r, rd, http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><meta charset="UTF-8"><title>cftunnel</title><p>已登录: %s（%s 下的所有路由）</p><p><a href="%s">登出</a></p>`,
+		html.EscapeString(user), html.EscapeString(p.cfg.PortalDomain), logoutPath)
+}
+
+// portalSession 校验门户签发的父域名 Cookie，返回门户会话
+func (p *Proxy) portalSession(r *http.Request) (*session.Session, bool) {
+	if p.cfg.Portal == nil {
+		return nil, false
+	}
+	return p.cfg.Portal.session(r)
+}
+
+// portalURL 返回门户上的地址，rd 为完成后跳回的地址
+func (p *Proxy) portalURL(path string, r *http.Request) string {
+	rd := "https://" + r.Host + r.URL.RequestURI()
+	return "https://" + p.cfg.Portal.cfg.Hostname + path + "?rd=" + url.QueryEscape(rd)
+}
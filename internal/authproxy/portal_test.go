@@ -0,0 +1,97 @@
+package authproxy
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// upstream 记录后端收到的 Cookie 和身份头
+type upstream struct {
+	*httptest.Server
+	cookie, user string
+}
+
+func newUpstream(t *testing.T) *upstream {
+	t.Helper()
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.cookie, u.user = r.Header.Get("Cookie"), r.Header.Get(DefaultUserHeader)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstream) port(t *testing.T) string {
+	t.Helper()
+	parsed, err := url.Parse(u.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Port()
+}
+
+func newTestProxy(t *testing.T, cfg Config) *Proxy {
+	t.Helper()
+	cfg.AuthLog = log.New(io.Discard, "", 0)
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.listener.Close() })
+	return p
+}
+
+// 门户 Cookie 写在父域名上，浏览器会发给所有子域名；任何经代理转发的请求都不应把它交给后端
+func TestSSOCookieNotForwarded(t *testing.T) {
+	up := newUpstream(t)
+	key := []byte("portal-key")
+	portal := newTestProxy(t, Config{
+		Name: "auth", Hostname: "auth.example.com", PortalDomain: "example.com",
+		Users:      map[string]string{"alice": "$2a$04$dOi1lg1vNiZEbN.839TvnOkzHj36QQtLTCcAQO2TI5skXpU71UlBm"},
+		TargetPort: up.port(t), SigningKey: key,
+	})
+	rec := httptest.NewRecorder()
+	if err := portal.issueCookie(rec, httptest.NewRequest("POST", loginPath, nil), "alice", nil); err != nil {
+		t.Fatal(err)
+	}
+	sso := rec.Result().Cookies()[0]
+	if sso.Name != ssoCookieName {
+		t.Fatalf("门户签发的 Cookie = %s, want %s", sso.Name, ssoCookieName)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		path     string
+		wantUser string
+	}{
+		{"未启用鉴权的路由", Config{Name: "open", Hostname: "open.example.com"}, "/", ""},
+		{"接入 SSO 的路由", Config{Name: "app", Hostname: "app.example.com", Portal: portal, SigningKey: []byte("app-key")}, "/", "alice"},
+		{"公开路径", Config{Name: "hook", Hostname: "hook.example.com", Portal: portal, SigningKey: []byte("hook-key"), PublicPaths: []string{"/webhook/*"}}, "/webhook/x", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.TargetPort = up.port(t)
+			p := newTestProxy(t, tt.cfg)
+			up.cookie, up.user = "", ""
+
+			r := httptest.NewRequest("GET", "https://"+tt.cfg.Hostname+tt.path, nil)
+			r.Header.Set("Cookie", "theme=dark; "+sso.Name+"="+sso.Value+"; "+cookieName+"=forged; "+csrfCookieName+"=x; lang=zh")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if up.cookie != "theme=dark; lang=zh" {
+				t.Errorf("后端收到 Cookie %q, want %q", up.cookie, "theme=dark; lang=zh")
+			}
+			if up.user != tt.wantUser {
+				t.Errorf("后端收到用户 %q, want %q", up.user, tt.wantUser)
+			}
+		})
+	}
+}
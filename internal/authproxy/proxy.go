@@ -7,7 +7,10 @@ import (
 	"crypto/sha256"
 	_ "embed"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"html/template"
+	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -15,13 +18,19 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/session"
 )
 
 //go:embed login.html
 var loginHTML []byte
 
 const cookieName = "__cftunnel_auth"
+
+// cookiePrefix cftunnel 写入的所有 Cookie 的名称前缀，转发到后端前删除
+const cookiePrefix = "__cftunnel_"
 const loginPath = "/___auth/login"
+const logoutPath = "/___auth/logout"
 
 // RandomKey 生成 32 字节随机签名密钥
 func RandomKey() []byte {
@@ -32,23 +41,72 @@ func RandomKey() []byte {
 
 // Config 鉴权代理配置
 type Config struct {
-	Username   string
-	Password   string
-	TargetPort string
-	SigningKey  []byte
-	CookieTTL  time.Duration
+	Name          string              // 路由名称（用于日志）
+	Hostname      string              // 路由公网域名（SSO 门户生成跳转地址时使用）
+	Users         map[string]string   // 用户名 → 密码哈希，明文密码一律校验失败
+	TOTP          map[string]string   // 用户名 → 两步验证密钥，设置后登录需输入验证码
+	HtpasswdFile  string              // 外部 htpasswd 文件，修改后自动重新加载
+	OIDC          *OIDCConfig         // 设置后使用 OIDC 登录，忽略用户名密码
+	Access        *AccessConfig       // 设置后要求请求携带有效的 Cloudflare Access JWT
+	IPFilter      *IPFilterConfig     // 按客户端 IP / 国家过滤（CF-Connecting-IP / CF-IPCountry）
+	AuthLog       *log.Logger         // 登录失败与锁定记录，nil 时写入标准日志
+	AccessLog     *AccessLogger       // 访问日志，nil 时不记录
+	Sessions      *session.Store      // 会话存储，nil 时仅保存在内存中
+	AnonymousWS   bool                // 允许未认证的 WebSocket 升级请求
+	PublicPaths   []string            // 无需鉴权的路径规则（前缀或通配符，见 ValidatePublicPath）
+	APIKeys       map[string]string   // API Key ID → SHA-256 摘要，通过 Authorization: Bearer 使用
+	Groups        map[string][]string // 用户名 → 所属组，写入上游的 X-Forwarded-Groups
+	Headers       IdentityHeaders     // 上游身份头名称与签名密钥
+	LoginTemplate string              // 自定义登录页模板（html/template，数据见 LoginPage），为空时使用内置页面
+	LoginTitle    string              // 登录页标题
+	LoginMessage  string              // 登录页说明文字
+	PortalDomain  string              // 设置时作为 SSO 门户：Cookie 写到该父域名，登录后跳回 rd 参数指定的地址
+	Portal        *Proxy              // SSO 门户，设置时接受门户签发的 Cookie，未登录的浏览器跳转门户登录
+	TargetPort    string
+	SigningKey    []byte
+	CookieTTL     time.Duration
 }
 
 // Proxy 鉴权反向代理
 type Proxy struct {
-	cfg      Config
-	listener net.Listener
-	server   *http.Server
-	reverse  *httputil.ReverseProxy
+	cfg       Config
+	listener  net.Listener
+	server    *http.Server
+	reverse   *httputil.ReverseProxy
+	htpasswd  *htpasswd
+	oidc      *oidcProvider
+	access    *accessVerifier
+	ipFilter  *ipFilter
+	limiter   *limiter
+	basic     *basicCache
+	totp      *totpGuard
+	headers   IdentityHeaders
+	loginPage *template.Template
 }
 
 // New 创建鉴权代理实例，自动探测可用端口
 func New(cfg Config) (*Proxy, error) {
+	var filter *ipFilter
+	if cfg.IPFilter != nil {
+		var err error
+		if filter, err = newIPFilter(*cfg.IPFilter); err != nil {
+			return nil, err
+		}
+	}
+
+	loginPage, err := parseLoginTemplate(cfg.LoginTemplate)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{cfg.Headers.User, cfg.Headers.Email, cfg.Headers.Groups} {
+		if name == "" {
+			continue
+		}
+		if err := ValidateHeaderName(name); err != nil {
+			return nil, err
+		}
+	}
+
 	port, _ := strconv.Atoi(cfg.TargetPort)
 	ln, err := FindAvailableListener(port + 1)
 	if err != nil {
@@ -62,15 +120,43 @@ func New(cfg Config) (*Proxy, error) {
 		cfg.CookieTTL = 24 * time.Hour
 	}
 
+	if cfg.AuthLog == nil {
+		cfg.AuthLog = log.Default()
+	}
+
+	if cfg.Sessions == nil {
+		cfg.Sessions = session.Memory()
+	}
+
 	p := &Proxy{
-		cfg:      cfg,
-		listener: ln,
-		reverse:  rp,
+		cfg:       cfg,
+		listener:  ln,
+		reverse:   rp,
+		ipFilter:  filter,
+		limiter:   newLimiter(),
+		basic:     newBasicCache(),
+		totp:      &totpGuard{used: make(map[string]int64)},
+		headers:   cfg.Headers.withDefaults(),
+		loginPage: loginPage,
+	}
+	if cfg.HtpasswdFile != "" {
+		p.htpasswd = &htpasswd{path: cfg.HtpasswdFile}
+	}
+	if cfg.OIDC != nil {
+		p.oidc = &oidcProvider{cfg: *cfg.OIDC}
+	}
+	if cfg.Access != nil {
+		p.access = newAccessVerifier(*cfg.Access)
 	}
 	p.server = &http.Server{Handler: p}
 	return p, nil
 }
 
+// Name 返回路由名称
+func (p *Proxy) Name() string {
+	return p.cfg.Name
+}
+
 // ListenPort 返回代理实际监听的端口
 func (p *Proxy) ListenPort() int {
 	return p.listener.Addr().(*net.TCPAddr).Port
@@ -89,91 +175,343 @@ func (p *Proxy) Stop() error {
 	return p.server.Shutdown(ctx)
 }
 
-// ServeHTTP 核心路由逻辑
+// ServeHTTP 处理请求，启用访问日志时记录每个请求
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// WebSocket 升级请求直接透传
-	if isWebSocket(r) {
-		p.reverse.ServeHTTP(w, r)
+	if p.cfg.AccessLog != nil {
+		p.logAccess(w, r, p.serve)
+		return
+	}
+	p.serve(w, r)
+}
+
+// serve 核心路由逻辑
+func (p *Proxy) serve(w http.ResponseWriter, r *http.Request) {
+	// IP / 国家过滤
+	if p.ipFilter != nil && !p.ipFilter.permit(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Cloudflare Access 校验：拒绝绕过 Access 直接访问源站的请求
+	var accessID identity
+	if p.access != nil {
+		email, ok := p.access.verify(r)
+		if !ok {
+			http.Error(w, "Forbidden: 缺少有效的 Cloudflare Access 凭据", http.StatusForbidden)
+			return
+		}
+		setUser(r, email)
+		accessID = p.userIdentity(email)
+	}
+
+	// 未配置登录方式（仅 Access 校验或 IP 过滤）时直接放行
+	if !p.loginRequired() {
+		p.proxy(w, r, accessID)
+		return
+	}
+
+	// OIDC 回调
+	if p.oidc != nil && r.URL.Path == callbackPath {
+		p.handleCallback(w, r)
 		return
 	}
 
 	// 登录表单提交
-	if r.Method == http.MethodPost && r.URL.Path == loginPath {
+	if p.oidc == nil && r.Method == http.MethodPost && r.URL.Path == loginPath {
 		p.handleLogin(w, r)
 		return
 	}
 
-	// 检查 Cookie 鉴权
-	if p.checkAuth(r) {
-		p.reverse.ServeHTTP(w, r)
+	// 登出
+	if r.URL.Path == logoutPath {
+		p.handleLogout(w, r)
+		return
+	}
+
+	// 分享链接兑换
+	if r.URL.Path == sharePath && !p.isPortal() {
+		p.handleShare(w, r)
+		return
+	}
+
+	// SSO 门户只负责登录，不转发到后端
+	if p.isPortal() {
+		p.servePortal(w, r)
+		return
+	}
+
+	// 公开路径无需鉴权（如 webhook、健康检查）
+	if p.isPublic(r.URL.Path) {
+		p.proxy(w, r, accessID)
+		return
+	}
+
+	// 检查 Cookie 鉴权（路由自身或 SSO 门户签发）
+	if sess, ok := p.session(r); ok {
+		setUser(r, sess.User)
+		p.proxy(w, r, p.sessionIdentity(sess))
+		return
+	}
+	if sess, ok := p.portalSession(r); ok {
+		setUser(r, sess.User)
+		p.proxy(w, r, p.cfg.Portal.sessionIdentity(sess))
+		return
+	}
+
+	// 检查 Authorization 头（Basic 用户名密码或 Bearer API Key）
+	if user, present, err := p.credentials(r); present {
+		if errors.Is(err, errBasicTOTP) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			p.unauthorized(w)
+			return
+		}
+		setUser(r, user)
+		// 凭据仅用于本代理，不转发给后端
+		r.Header.Del("Authorization")
+		p.proxy(w, r, p.userIdentity(user))
+		return
+	}
+
+	// 路由显式允许匿名 WebSocket 时放行
+	if isWebSocket(r) && p.cfg.AnonymousWS {
+		p.proxy(w, r, accessID)
+		return
+	}
+
+	// 非浏览器客户端（curl、脚本、WebSocket 等）无法使用登录页，返回 401 质询
+	if isWebSocket(r) || !wantsHTML(r) {
+		p.unauthorized(w)
+		return
+	}
+
+	// 未认证，使用 SSO 时跳转门户登录
+	if p.cfg.Portal != nil {
+		http.Redirect(w, r, p.portalURL(loginPath, r), http.StatusFound)
+		return
+	}
+
+	// 未认证，OIDC 模式跳转身份提供商
+	if p.oidc != nil {
+		p.startOIDC(w, r)
 		return
 	}
 
 	// 未认证，返回登录页
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write(loginHTML)
+	p.renderLogin(w, r, http.StatusOK, nil)
+}
+
+// loginRequired 是否配置了登录方式（用户、htpasswd、OIDC、API Key 或 SSO 门户）
+func (p *Proxy) loginRequired() bool {
+	return len(p.cfg.Users) > 0 || p.htpasswd != nil || p.oidc != nil || len(p.cfg.APIKeys) > 0 || p.cfg.Portal != nil
 }
 
 // handleLogin 处理登录表单提交
 func (p *Proxy) handleLogin(w http.ResponseWriter, r *http.Request) {
+	ip := ClientIP(r)
+	if !p.verifyCSRF(r) {
+		p.renderLogin(w, r, http.StatusForbidden, func(t LoginText) string { return t.Expired })
+		return
+	}
+	if d := p.limiter.acquire(ip); d > 0 {
+		secs := int(d.Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		p.renderLogin(w, r, http.StatusTooManyRequests, func(t LoginText) string { return fmt.Sprintf(t.Locked, secs) })
+		return
+	}
+
 	username := r.FormValue("username")
 	password := r.FormValue("password")
 
-	if username != p.cfg.Username || password != p.cfg.Password {
-		http.Redirect(w, r, "/?error=1", http.StatusSeeOther)
+	if !p.verifyUser(username, password) || !p.verifyTOTP(username, r.FormValue("totp")) {
+		p.loginFailed(ip, username)
+		p.renderLogin(w, r, http.StatusUnauthorized, func(t LoginText) string { return t.Invalid })
+		return
+	}
+	p.limiter.reset(ip)
+	setUser(r, username)
+
+	if err := p.issueCookie(w, r, username, nil); err != nil {
+		p.cfg.AuthLog.Printf("登记会话失败 route=%s: %v", p.cfg.Name, err)
+		http.Error(w, "登录失败，请稍后重试", http.StatusInternalServerError)
 		return
 	}
+	dest := "/"
+	if rd := p.portalReturn(r); rd != "" {
+		dest = rd
+	}
+	http.Redirect(w, r, dest, http.StatusSeeOther)
+}
+
+// handleLogout 删除服务端会话并清除 Cookie；使用 SSO 时继续到门户登出
+func (p *Proxy) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if _, sid, ok := p.parseCookie(r); ok {
+		if _, err := p.cfg.Sessions.Remove(func(s session.Session) bool { return s.ID == sid }); err != nil {
+			p.cfg.AuthLog.Printf("删除会话失败 route=%s: %v", p.cfg.Name, err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     p.cookieName(),
+		Value:    "",
+		Path:     "/",
+		Domain:   p.cfg.PortalDomain,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	switch {
+	case p.cfg.Portal != nil:
+		r.URL.Path, r.URL.RawQuery = "/", ""
+		http.Redirect(w, r, p.portalURL(logoutPath, r), http.StatusSeeOther)
+	case p.portalReturn(r) != "":
+		http.Redirect(w, r, p.portalReturn(r), http.StatusSeeOther)
+	default:
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
 
-	// 签发 Cookie
-	expiry := time.Now().Add(p.cfg.CookieTTL).Unix()
-	payload := fmt.Sprintf("%s:%x", username, expiry)
+// loginFailed 记录一次登录失败并累计限流计数
+func (p *Proxy) loginFailed(ip, username string) {
+	failures, d := p.limiter.fail(ip)
+	p.cfg.AuthLog.Printf("登录失败 route=%s ip=%s user=%q failures=%d", p.cfg.Name, ip, username, failures)
+	if d >= lockoutDuration {
+		p.cfg.AuthLog.Printf("已锁定 route=%s ip=%s duration=%s", p.cfg.Name, ip, d)
+	}
+}
+
+// issueCookie 登记服务端会话并签发鉴权 Cookie，groups 为身份提供商返回的组
+func (p *Proxy) issueCookie(w http.ResponseWriter, r *http.Request, username string, groups []string) error {
+	now := time.Now()
+	sess := session.Session{
+		ID:          session.NewID(),
+		User:        username,
+		Created:     now,
+		Expires:     now.Add(p.cfg.CookieTTL),
+		IP:          ClientIP(r),
+		UserAgent:   r.UserAgent(),
+		PasswordTag: p.passwordTag(username),
+		Groups:      groups,
+	}
+	if err := p.cfg.Sessions.Add(sess); err != nil {
+		return err
+	}
+	p.setSessionCookie(w, sess)
+	return nil
+}
+
+// setSessionCookie 签发会话对应的鉴权 Cookie
+func (p *Proxy) setSessionCookie(w http.ResponseWriter, sess session.Session) {
+	payload := fmt.Sprintf("%s:%s:%x", sess.User, sess.ID, sess.Expires.Unix())
 	sig := signPayload(p.cfg.SigningKey, payload)
 	value := payload + "." + sig
 
 	http.SetCookie(w, &http.Cookie{
-		Name:     cookieName,
+		Name:     p.cookieName(),
 		Value:    value,
 		Path:     "/",
-		MaxAge:   int(p.cfg.CookieTTL.Seconds()),
+		Domain:   p.cfg.PortalDomain,
+		MaxAge:   int(time.Until(sess.Expires).Seconds()),
 		HttpOnly: true,
 		Secure:   true,
 		SameSite: http.SameSiteLaxMode,
 	})
-	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-// checkAuth 校验请求中的鉴权 Cookie
-func (p *Proxy) checkAuth(r *http.Request) bool {
-	cookie, err := r.Cookie(cookieName)
+// parseCookie 校验 Cookie 签名和有效期，返回用户名和会话 ID
+func (p *Proxy) parseCookie(r *http.Request) (username, sid string, ok bool) {
+	cookie, err := r.Cookie(p.cookieName())
 	if err != nil {
-		return false
+		return "", "", false
 	}
 
-	// 格式：username:expiry_hex.hmac_hex
+	// 格式：username:session_id:expiry_hex.hmac_hex
 	dotIdx := strings.LastIndex(cookie.Value, ".")
 	if dotIdx < 0 {
-		return false
+		return "", "", false
 	}
 	payload := cookie.Value[:dotIdx]
 	sig := cookie.Value[dotIdx+1:]
 
 	// 验证签名
-	if signPayload(p.cfg.SigningKey, payload) != sig {
-		return false
+	if !verifySignature(p.cfg.SigningKey, payload, sig) {
+		return "", "", false
 	}
 
 	// 验证过期时间
 	colonIdx := strings.LastIndex(payload, ":")
 	if colonIdx < 0 {
-		return false
+		return "", "", false
 	}
-	expiryHex := payload[colonIdx+1:]
-	expiry, err := strconv.ParseInt(expiryHex, 16, 64)
-	if err != nil {
-		return false
+	expiry, err := strconv.ParseInt(payload[colonIdx+1:], 16, 64)
+	if err != nil || time.Now().Unix() >= expiry {
+		return "", "", false
 	}
-	return time.Now().Unix() < expiry
+	// 用户名可能包含冒号，会话 ID 取倒数第二段
+	rest := payload[:colonIdx]
+	sidIdx := strings.LastIndex(rest, ":")
+	if sidIdx < 0 {
+		return "", "", false
+	}
+	return rest[:sidIdx], rest[sidIdx+1:], true
+}
+
+// checkAuth 校验请求中的鉴权 Cookie 及其服务端会话，返回登录的用户名
+func (p *Proxy) checkAuth(r *http.Request) (string, bool) {
+	sess, ok := p.session(r)
+	if !ok {
+		return "", false
+	}
+	return sess.User, true
+}
+
+// session 返回请求 Cookie 对应的有效会话
+func (p *Proxy) session(r *http.Request) (*session.Session, bool) {
+	username, sid, ok := p.parseCookie(r)
+	if !ok {
+		return nil, false
+	}
+	// 会话已登出、被吊销或用户密码已修改
+	sess, ok := p.cfg.Sessions.Get(sid)
+	if !ok || sess.User != username {
+		return nil, false
+	}
+	// 分享链接兑换的会话不对应用户，有效期至链接过期
+	if sess.Share != "" {
+		return sess, true
+	}
+	if sess.PasswordTag != p.passwordTag(username) {
+		return nil, false
+	}
+	// 用户被移除（或不再被允许）后已签发的 Cookie 立即失效
+	if p.oidc != nil {
+		return sess, p.oidc.allowed(username)
+	}
+	_, ok = p.lookupUser(username)
+	return sess, ok
+}
+
+// sessionIdentity 会话对应的身份，OIDC 登录时使用 ID Token 中的组
+func (p *Proxy) sessionIdentity(sess *session.Session) identity {
+	id := p.userIdentity(sess.User)
+	if len(sess.Groups) > 0 {
+		id.Groups = sess.Groups
+	}
+	return id
+}
+
+// passwordTag 用户当前密码哈希的指纹（OIDC 用户为空），用于在密码修改后使旧会话失效
+func (p *Proxy) passwordTag(username string) string {
+	if p.oidc != nil {
+		return ""
+	}
+	hash, ok := p.lookupUser(username)
+	if !ok {
+		return ""
+	}
+	return signPayload(p.cfg.SigningKey, "password:"+hash)[:16]
 }
 
 // signPayload 使用 HMAC-SHA256 签名
@@ -183,6 +521,11 @@ func signPayload(key []byte, payload string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
+// verifySignature 恒定时间比较 HMAC 签名
+func verifySignature(key []byte, payload, sig string) bool {
+	return hmac.Equal([]byte(signPayload(key, payload)), []byte(sig))
+}
+
 // isWebSocket 检测是否为 WebSocket 升级请求
 func isWebSocket(r *http.Request) bool {
 	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
@@ -0,0 +1,54 @@
+package authproxy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// 公开路径规则：
+//   /healthz     前缀匹配（按路径段），匹配 /healthz 与 /healthz/...
+//   /webhook/*   以 * 结尾，匹配 /webhook/ 下的任意层级
+//   /api/*/ping  其他通配符按 path.Match 语义匹配（* 不跨越 /）
+
+// ValidatePublicPath 检查公开路径规则格式
+func ValidatePublicPath(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("公开路径 %q 必须以 / 开头", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("公开路径 %q 格式错误: %w", pattern, err)
+	}
+	return nil
+}
+
+// matchPublicPath 判断路径是否匹配规则
+func matchPublicPath(pattern, p string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && !strings.ContainsAny(prefix, "*?[\\") {
+		return strings.HasPrefix(p, prefix)
+	}
+	if strings.ContainsAny(pattern, "*?[\\") {
+		ok, _ := path.Match(pattern, p)
+		return ok
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+	return p == pattern || strings.HasPrefix(p, pattern+"/")
+}
+
+// isPublic 请求路径是否无需鉴权
+// 含 ./.. 等未规范化片段的路径一律不放行，避免后端解析后越过规则（如 /webhook/../admin）
+func (p *Proxy) isPublic(reqPath string) bool {
+	if len(p.cfg.PublicPaths) == 0 {
+		return false
+	}
+	clean := path.Clean(reqPath)
+	if clean != reqPath && clean+"/" != reqPath {
+		return false
+	}
+	for _, pattern := range p.cfg.PublicPaths {
+		if matchPublicPath(pattern, reqPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package authproxy
+
+import "testing"
+
+func TestMatchPublicPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/healthz", "/healthz", true},
+		{"/healthz", "/healthz/live", true},
+		{"/healthz", "/healthzz", false},
+		{"/healthz/", "/healthz", true},
+		{"/webhook/*", "/webhook/github", true},
+		{"/webhook/*", "/webhook/a/b/c", true},
+		{"/webhook/*", "/webhook", false},
+		{"/webhook/*", "/webhooks/x", false},
+		{"/api/*/ping", "/api/v1/ping", true},
+		{"/api/*/ping", "/api/v1/v2/ping", false},
+		{"/api/*/ping", "/api/v1/pong", false},
+		{"/file?.txt", "/file1.txt", true},
+		{"/", "/anything", true},
+	}
+	for _, tt := range tests {
+		if got := matchPublicPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPublicPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	p := &Proxy{cfg: Config{PublicPaths: []string{"/healthz", "/webhook/*"}}}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/healthz", true},
+		{"/webhook/github", true},
+		{"/webhook/github/", true},
+		{"/webhook/../admin", false},
+		{"/webhook/./github", false},
+		{"/webhook//github", false},
+		{"/admin", false},
+	}
+	for _, tt := range tests {
+		if got := p.isPublic(tt.path); got != tt.want {
+			t.Errorf("isPublic(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if (&Proxy{}).isPublic("/healthz") {
+		t.Error("未配置公开路径时不应放行")
+	}
+}
+
+func TestValidatePublicPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"/healthz", false},
+		{"/webhook/*", false},
+		{"healthz", true},
+		{"/bad[", true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePublicPath(tt.pattern); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePublicPath(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package authproxy
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	freeAttempts    = 3                // 前几次失败不限速
+	maxBackoff      = 5 * time.Minute  // 指数退避上限
+	lockoutAfter    = 10               // 连续失败达到该次数后锁定
+	lockoutDuration = 15 * time.Minute // 锁定时长
+	forgetAfter     = time.Hour        // 超过该时间无失败则清零
+)
+
+// attempt 单个客户端的登录失败状态
+type attempt struct {
+	failures int
+	next     time.Time // 在此之前拒绝登录尝试
+	last     time.Time
+}
+
+// limiter 按客户端 IP 限制登录失败次数：指数退避 + 临时锁定
+type limiter struct {
+	mu      sync.Mutex
+	clients map[string]*attempt
+}
+
+func newLimiter() *limiter {
+	return &limiter{clients: make(map[string]*attempt)}
+}
+
+// acquire 在校验密码前原子地预占一次尝试：仍需等待时返回等待时间；否则先按失败计数，
+// 校验成功后 reset 撤销，失败后 fail 确认。并发请求无法在记录失败前一起越过退避和锁定
+func (l *limiter) acquire(ip string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.prune(now)
+
+	a, ok := l.clients[ip]
+	if ok {
+		if d := a.next.Sub(now); d > 0 {
+			return d
+		}
+	}
+	if !ok || now.Sub(a.last) > forgetAfter {
+		a = &attempt{}
+		l.clients[ip] = a
+	}
+	a.failures++
+	a.last = now
+	a.next = now.Add(backoff(a.failures))
+	return 0
+}
+
+// fail 确认预占的尝试失败，返回累计失败次数和下次允许尝试前的等待时间
+func (l *limiter) fail(ip string) (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.clients[ip]
+	if !ok {
+		return 0, 0
+	}
+	return a.failures, backoff(a.failures)
+}
+
+// backoff 累计失败 n 次后的等待时间
+func backoff(n int) time.Duration {
+	switch {
+	case n >= lockoutAfter:
+		return lockoutDuration
+	case n > freeAttempts:
+		return min(time.Second<<(n-freeAttempts-1), maxBackoff)
+	}
+	return 0
+}
+
+// reset 登录成功后清除失败记录
+func (l *limiter) reset(ip string) {
+	l.mu.Lock()
+	delete(l.clients, ip)
+	l.mu.Unlock()
+}
+
+// prune 清理过期记录，防止扫描流量撑大内存（调用方持有锁）
+func (l *limiter) prune(now time.Time) {
+	if len(l.clients) < 1024 {
+		return
+	}
+	for ip, a := range l.clients {
+		if now.Sub(a.last) > forgetAfter && now.After(a.next) {
+			delete(l.clients, ip)
+		}
+	}
+}
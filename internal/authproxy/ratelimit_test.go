@@ -0,0 +1,105 @@
+package authproxy
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{freeAttempts, 0},
+		{freeAttempts + 1, time.Second},
+		{freeAttempts + 2, 2 * time.Second},
+		{lockoutAfter - 1, 32 * time.Second},
+		{lockoutAfter, lockoutDuration},
+		{lockoutAfter + 5, lockoutDuration},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int // 依次预占并确认失败的次数（每次之前跳过等待）
+		wantFail int
+		wantWait bool // 最后一次失败后是否需要等待
+	}{
+		{"免限速次数内", freeAttempts, freeAttempts, false},
+		{"开始退避", freeAttempts + 1, freeAttempts + 1, true},
+		{"锁定", lockoutAfter, lockoutAfter, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter()
+			var failures int
+			var d time.Duration
+			for i := 0; i < tt.attempts; i++ {
+				if a := l.clients["ip"]; a != nil {
+					a.next = time.Time{}
+				}
+				if w := l.acquire("ip"); w != 0 {
+					t.Fatalf("第 %d 次预占被拒绝: %s", i+1, w)
+				}
+				failures, d = l.fail("ip")
+			}
+			if failures != tt.wantFail {
+				t.Errorf("failures = %d, want %d", failures, tt.wantFail)
+			}
+			if (d > 0) != tt.wantWait || (l.acquire("ip") > 0) != tt.wantWait {
+				t.Errorf("等待 = %s, want wait=%v", d, tt.wantWait)
+			}
+		})
+	}
+}
+
+// 并发请求在任何失败确认之前同时到达，也只能放行免限速次数加一次
+func TestLimiterAcquireConcurrent(t *testing.T) {
+	l := newLimiter()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.acquire("ip") == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != freeAttempts+1 {
+		t.Errorf("allowed = %d, want %d", allowed, freeAttempts+1)
+	}
+}
+
+func TestLimiterReset(t *testing.T) {
+	l := newLimiter()
+	for i := 0; i < lockoutAfter; i++ {
+		l.acquire("ip")
+		if a := l.clients["ip"]; i < lockoutAfter-1 {
+			a.next = time.Time{}
+		}
+	}
+	if l.acquire("ip") == 0 {
+		t.Fatal("锁定后仍允许尝试")
+	}
+	if l.acquire("other") != 0 {
+		t.Error("其他 IP 不应受影响")
+	}
+	l.reset("ip")
+	if d := l.acquire("ip"); d != 0 {
+		t.Errorf("reset 后仍需等待 %s", d)
+	}
+}
@@ -0,0 +1,93 @@
+package authproxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/session"
+)
+
+const sharePath = "/___auth/share"
+
+// ShareToken 分享链接内容，使用路由的 SigningKey 签名，无需服务端预先登记
+type ShareToken struct {
+	ID      string `json:"id"`
+	Path    string `json:"path,omitempty"` // 兑换后跳转的路径，默认 /
+	Expires int64  `json:"exp"`            // Unix 秒
+	MaxUses int    `json:"max,omitempty"`  // 最多兑换次数，0 表示不限
+}
+
+// ShareURL 生成分享链接
+func ShareURL(hostname string, key []byte, t ShareToken) string {
+	data, _ := json.Marshal(t)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	token := payload + "." + signPayload(key, "share:"+payload)
+	return "https://" + hostname + sharePath + "?token=" + token
+}
+
+// parseShareToken 校验分享链接签名和有效期
+func (p *Proxy) parseShareToken(token string) (*ShareToken, bool) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !verifySignature(p.cfg.SigningKey, "share:"+payload, sig) {
+		return nil, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, false
+	}
+	var t ShareToken
+	if json.Unmarshal(data, &t) != nil || t.ID == "" || time.Now().Unix() >= t.Expires {
+		return nil, false
+	}
+	return &t, true
+}
+
+// handleShare 兑换分享链接：校验通过且未超过使用次数时签发会话 Cookie（有效期至链接过期）
+func (p *Proxy) handleShare(w http.ResponseWriter, r *http.Request) {
+	ip := ClientIP(r)
+	t, ok := p.parseShareToken(r.URL.Query().Get("token"))
+	if !ok {
+		p.cfg.AuthLog.Printf("分享链接无效 route=%s ip=%s", p.cfg.Name, ip)
+		http.Error(w, "分享链接无效或已过期", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	sess := session.Session{
+		ID:        session.NewID(),
+		User:      "share:" + t.ID,
+		Created:   now,
+		Expires:   time.Unix(t.Expires, 0),
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+		Share:     t.ID,
+	}
+	if err := p.cfg.Sessions.Redeem(sess, t.MaxUses); err != nil {
+		if errors.Is(err, session.ErrShareRevoked) {
+			p.cfg.AuthLog.Printf("分享链接已吊销 route=%s ip=%s share=%s", p.cfg.Name, ip, t.ID)
+			http.Error(w, "分享链接已被吊销", http.StatusGone)
+			return
+		}
+		if errors.Is(err, session.ErrShareUsedUp) {
+			p.cfg.AuthLog.Printf("分享链接已用尽 route=%s ip=%s share=%s", p.cfg.Name, ip, t.ID)
+			http.Error(w, "分享链接已达到使用次数上限", http.StatusGone)
+			return
+		}
+		p.cfg.AuthLog.Printf("登记会话失败 route=%s: %v", p.cfg.Name, err)
+		http.Error(w, "登录失败，请稍后重试", http.StatusInternalServerError)
+		return
+	}
+	setUser(r, sess.User)
+	p.setSessionCookie(w, sess)
+
+	dest := "/"
+	if t.Path != "" {
+		dest = (&url.URL{Path: t.Path}).String()
+	}
+	http.Redirect(w, r, safeReturn(dest), http.StatusSeeOther)
+}
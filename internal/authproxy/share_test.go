@@ -0,0 +1,57 @@
+package authproxy
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shareToken 从分享链接中取出 token 参数
+func shareToken(t *testing.T, link string) string {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != sharePath {
+		t.Fatalf("链接路径 = %s, want %s", u.Path, sharePath)
+	}
+	return u.Query().Get("token")
+}
+
+func TestParseShareToken(t *testing.T) {
+	key := []byte("route-signing-key")
+	p := &Proxy{cfg: Config{SigningKey: key}}
+	valid := ShareToken{ID: "abc", Path: "/demo", Expires: time.Now().Add(time.Hour).Unix(), MaxUses: 1}
+	token := shareToken(t, ShareURL("a.example.com", key, valid))
+	payload, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"有效", token, true},
+		{"其他路由的密钥", shareToken(t, ShareURL("a.example.com", []byte("other"), valid)), false},
+		{"已过期", shareToken(t, ShareURL("a.example.com", key, ShareToken{ID: "abc", Expires: time.Now().Add(-time.Second).Unix()})), false},
+		{"缺少 ID", shareToken(t, ShareURL("a.example.com", key, ShareToken{Expires: time.Now().Add(time.Hour).Unix()})), false},
+		{"篡改内容", base64.RawURLEncoding.EncodeToString([]byte(`{"id":"abc","exp":9999999999}`)) + "." + sig, false},
+		{"篡改签名", payload + "." + strings.Repeat("0", len(sig)), false},
+		{"鉴权 Cookie 的签名域", payload + "." + signPayload(key, payload), false},
+		{"缺少签名", payload, false},
+		{"空", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.parseShareToken(tt.token)
+			if ok != tt.want {
+				t.Fatalf("parseShareToken ok = %v, want %v", ok, tt.want)
+			}
+			if ok && *got != valid {
+				t.Errorf("parseShareToken = %+v, want %+v", *got, valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package authproxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/totp"
+)
+
+// totpGuard 记录每个用户最近一次使用的验证码步数，拒绝重复使用（重放）
+type totpGuard struct {
+	mu   sync.Mutex
+	used map[string]int64
+}
+
+// accept 步数必须大于该用户上次使用的步数
+func (g *totpGuard) accept(username string, counter int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if last, ok := g.used[username]; ok && counter <= last {
+		return false
+	}
+	g.used[username] = counter
+	return true
+}
+
+// requiresTOTP 用户是否启用了两步验证
+func (p *Proxy) requiresTOTP(username string) bool {
+	_, ok := p.cfg.TOTP[username]
+	return ok
+}
+
+// verifyTOTP 校验两步验证码，未启用两步验证的用户直接通过
+func (p *Proxy) verifyTOTP(username, code string) bool {
+	secret, ok := p.cfg.TOTP[username]
+	if !ok {
+		return true
+	}
+	counter, ok := totp.Verify(secret, code, time.Now())
+	return ok && p.totp.accept(username, counter)
+}
@@ -0,0 +1,66 @@
+package authproxy
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/passwd"
+)
+
+// htpasswd htpasswd 文件缓存，文件修改时间变化时重新加载
+type htpasswd struct {
+	mu    sync.Mutex
+	path  string
+	mod   time.Time
+	users map[string]string
+}
+
+func (h *htpasswd) lookup(username string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	info, err := os.Stat(h.path)
+	if err != nil {
+		// 文件被删除视为无用户；读取失败则保留上次结果
+		if os.IsNotExist(err) {
+			h.users, h.mod = nil, time.Time{}
+		}
+	} else if !info.ModTime().Equal(h.mod) {
+		users, err := passwd.ParseHtpasswd(h.path)
+		if err != nil {
+			log.Printf("加载 htpasswd 失败: %v", err)
+		} else {
+			h.users, h.mod = users, info.ModTime()
+		}
+	}
+	hash, ok := h.users[username]
+	return hash, ok
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// verifyUser 校验用户名和密码；用户不存在时仍执行一次哈希校验，避免通过响应时间探测用户名
+func (p *Proxy) verifyUser(username, password string) bool {
+	hash, ok := p.lookupUser(username)
+	if !ok {
+		dummyOnce.Do(func() { dummyHash, _ = passwd.Hash("cftunnel") })
+		passwd.Verify(dummyHash, password)
+		return false
+	}
+	return passwd.Verify(hash, password)
+}
+
+// lookupUser 查找用户的密码哈希（配置中的用户优先，其次 htpasswd 文件）
+func (p *Proxy) lookupUser(username string) (string, bool) {
+	if hash, ok := p.cfg.Users[username]; ok {
+		return hash, true
+	}
+	if p.htpasswd != nil {
+		return p.htpasswd.lookup(username)
+	}
+	return "", false
+}
@@ -5,17 +5,28 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/qingchencloud/cftunnel/internal/passwd"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Version     int               `yaml:"version"`
-	Auth        AuthConfig        `yaml:"auth"`
-	Tunnel      TunnelConfig      `yaml:"tunnel"`
-	Routes      []RouteConfig     `yaml:"routes"`
-	Relay       RelayConfig       `yaml:"relay,omitempty"`
-	Cloudflared CloudflaredConfig `yaml:"cloudflared"`
-	SelfUpdate  SelfUpdateConfig  `yaml:"self_update"`
+	Version        int                 `yaml:"version"`
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Auth           AuthConfig          `yaml:"auth"`
+	Tunnel         TunnelConfig        `yaml:"tunnel"`
+	Routes         []RouteConfig       `yaml:"routes"`
+	Relay          RelayConfig         `yaml:"relay,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+	Cloudflared    CloudflaredConfig   `yaml:"cloudflared"`
+	SelfUpdate     SelfUpdateConfig    `yaml:"self_update"`
+	AccessLog      AccessLogConfig     `yaml:"access_log,omitempty"`
+	Secrets        SecretsConfig       `yaml:"secrets,omitempty"`
+
+	active string  // 当前加载到顶层字段的上下文
+	base   Profile // default 上下文（激活其他上下文时暂存）
+	locked bool    // 由 Update 加载，已持有文件锁
+
+	staleKeyring *string // 更换主密钥后待删除的旧密钥环条目，Save 成功后删除
 }
 
 type AuthConfig struct {
@@ -30,20 +41,127 @@ type TunnelConfig struct {
 }
 
 type RouteConfig struct {
-	Name        string     `yaml:"name"`
-	Hostname    string     `yaml:"hostname"`
-	Service     string     `yaml:"service"`
-	ZoneID      string     `yaml:"zone_id"`
-	DNSRecordID string     `yaml:"dns_record_id"`
-	Auth        *AuthProxy `yaml:"auth,omitempty"`
+	Name        string      `yaml:"name"`
+	Hostname    string      `yaml:"hostname"`
+	Service     string      `yaml:"service"`
+	ZoneID      string      `yaml:"zone_id"`
+	DNSRecordID string      `yaml:"dns_record_id"`
+	Auth        *AuthProxy  `yaml:"auth,omitempty"`
+	Access      *AccessAuth `yaml:"access,omitempty"`
+	IPFilter    *IPFilter   `yaml:"ip_filter,omitempty"`
+	AccessLog   bool        `yaml:"access_log,omitempty"` // 无鉴权的路由也经代理转发以记录访问日志
+}
+
+// IPFilter 按客户端 IP / 国家限制访问（经 cloudflared 转发的 CF-Connecting-IP / CF-IPCountry），deny 优先
+type IPFilter struct {
+	Allow          []string `yaml:"allow,omitempty"`           // CIDR 或单个 IP，非空时仅放行匹配项
+	Deny           []string `yaml:"deny,omitempty"`            // CIDR 或单个 IP
+	AllowCountries []string `yaml:"allow_countries,omitempty"` // ISO 国家代码，如 CN、US
+	DenyCountries  []string `yaml:"deny_countries,omitempty"`
+}
+
+// AccessAuth Cloudflare Access JWT 校验（拒绝绕过 Access 直连源站的请求）
+type AccessAuth struct {
+	TeamDomain string `yaml:"team_domain"` // 如 myteam.cloudflareaccess.com
+	AUD        string `yaml:"aud"`         // Access 应用的 Audience 标签
 }
 
 // AuthProxy 鉴权代理配置
 type AuthProxy struct {
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	SigningKey string `yaml:"signing_key,omitempty"`
-	CookieTTL int    `yaml:"cookie_ttl,omitempty"` // 秒，默认 86400
+	Users        []AuthUser       `yaml:"users,omitempty"`
+	HtpasswdFile string           `yaml:"htpasswd_file,omitempty"`             // 外部 htpasswd 文件（bcrypt/SHA/apr1），与 users 合并生效
+	OIDC         *OIDCAuth        `yaml:"oidc,omitempty"`                      // 设置后使用 OIDC 登录，users/htpasswd 不生效
+	AnonymousWS  bool             `yaml:"allow_anonymous_websocket,omitempty"` // 允许未登录的 WebSocket 连接
+	APIKeys      []APIKey         `yaml:"api_keys,omitempty"`                  // 供脚本使用的 Bearer 密钥
+	PublicPaths  []string         `yaml:"public_paths,omitempty"`              // 无需鉴权的路径（前缀或通配符，如 /healthz、/webhook/*）
+	PortalDomain string           `yaml:"portal_domain,omitempty"`             // 作为 SSO 门户，登录 Cookie 写到该父域名（如 example.com）
+	SSO          string           `yaml:"sso,omitempty"`                       // 通过指定的门户路由登录（单点登录）
+	Headers      *IdentityHeaders `yaml:"identity_headers,omitempty"`          // 上游身份头名称与签名密钥，省略时使用默认头且不签名
+	LoginPage    *LoginPage       `yaml:"login_page,omitempty"`                // 自定义登录页（模板、标题、说明文字）
+	SigningKey   string           `yaml:"signing_key,omitempty"`
+	CookieTTL    int              `yaml:"cookie_ttl,omitempty"` // 秒，默认 86400
+}
+
+// LoginPage 登录页定制，模板为 html/template 文件，可用字段见 authproxy.LoginPage
+type LoginPage struct {
+	Template string `yaml:"template,omitempty"` // 模板文件路径，为空时使用内置页面
+	Title    string `yaml:"title,omitempty"`    // 标题，为空时按访问者语言显示默认文字
+	Message  string `yaml:"message,omitempty"`  // 标题下方的说明文字
+}
+
+// IdentityHeaders 转发给后端的身份头配置（如 Grafana auth proxy 模式），名称为空时使用默认值
+type IdentityHeaders struct {
+	User   string `yaml:"user,omitempty"`   // 默认 X-Forwarded-User
+	Email  string `yaml:"email,omitempty"`  // 默认 X-Forwarded-Email
+	Groups string `yaml:"groups,omitempty"` // 默认 X-Forwarded-Groups
+	Secret string `yaml:"secret,omitempty"` // 设置后附加 X-Forwarded-Auth-Signature 签名头
+}
+
+// OIDCAuth OpenID Connect 登录配置（授权码 + PKCE）
+type OIDCAuth struct {
+	Issuer         string   `yaml:"issuer"`
+	ClientID       string   `yaml:"client_id"`
+	ClientSecret   string   `yaml:"client_secret,omitempty"` // 公共客户端可省略
+	AllowedEmails  []string `yaml:"allowed_emails,omitempty"`
+	AllowedDomains []string `yaml:"allowed_domains,omitempty"`
+}
+
+// AuthUser 鉴权用户
+type AuthUser struct {
+	Username   string   `yaml:"username"`
+	Password   string   `yaml:"password"`              // bcrypt/argon2id 哈希，旧版明文在下次保存时自动转换
+	TOTPSecret string   `yaml:"totp_secret,omitempty"` // 两步验证密钥（Base32），设置后登录需输入验证码
+	Groups     []string `yaml:"groups,omitempty"`      // 所属组，写入上游的 X-Forwarded-Groups
+}
+
+// APIKey 路由 API Key，仅保存密钥摘要
+type APIKey struct {
+	ID      string `yaml:"id"`
+	Name    string `yaml:"name,omitempty"`
+	Hash    string `yaml:"hash"` // SHA-256(密钥)
+	Created string `yaml:"created,omitempty"`
+}
+
+// FindAPIKey 按 ID 或名称查找 API Key
+func (a *AuthProxy) FindAPIKey(idOrName string) *APIKey {
+	for i := range a.APIKeys {
+		if a.APIKeys[i].ID == idOrName || a.APIKeys[i].Name == idOrName {
+			return &a.APIKeys[i]
+		}
+	}
+	return nil
+}
+
+// RemoveAPIKey 按 ID 删除 API Key，返回是否存在
+func (a *AuthProxy) RemoveAPIKey(id string) bool {
+	for i, k := range a.APIKeys {
+		if k.ID == id {
+			a.APIKeys = append(a.APIKeys[:i], a.APIKeys[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// FindUser 按用户名查找用户
+func (a *AuthProxy) FindUser(username string) *AuthUser {
+	for i := range a.Users {
+		if a.Users[i].Username == username {
+			return &a.Users[i]
+		}
+	}
+	return nil
+}
+
+// RemoveUser 删除用户，返回是否存在
+func (a *AuthProxy) RemoveUser(username string) bool {
+	for i, u := range a.Users {
+		if u.Username == username {
+			a.Users = append(a.Users[:i], a.Users[i+1:]...)
+			return true
+		}
+	}
+	return false
 }
 
 // CookieTTLOrDefault 返回 Cookie 有效期（秒），默认 86400
@@ -76,6 +194,30 @@ type CloudflaredConfig struct {
 	AutoUpdate bool   `yaml:"auto_update"`
 }
 
+// AccessLogConfig 访问日志配置（记录经过鉴权代理的请求）
+type AccessLogConfig struct {
+	Format     string `yaml:"format,omitempty"`      // json（默认）或 combined
+	MaxSizeMB  int    `yaml:"max_size_mb,omitempty"` // 单个文件上限，默认 10
+	MaxBackups int    `yaml:"max_backups,omitempty"` // 保留的轮转文件数，默认 5
+	Disabled   bool   `yaml:"disabled,omitempty"`
+}
+
+// MaxSizeMBOrDefault 返回单个访问日志文件上限（MB），默认 10
+func (a AccessLogConfig) MaxSizeMBOrDefault() int {
+	if a.MaxSizeMB > 0 {
+		return a.MaxSizeMB
+	}
+	return 10
+}
+
+// MaxBackupsOrDefault 返回保留的轮转文件数，默认 5
+func (a AccessLogConfig) MaxBackupsOrDefault() int {
+	if a.MaxBackups > 0 {
+		return a.MaxBackups
+	}
+	return 5
+}
+
 type SelfUpdateConfig struct {
 	AutoCheck bool `yaml:"auto_check"` // 启动时自动检查 cftunnel 更新
 }
@@ -87,10 +229,15 @@ var (
 )
 
 // Dir 返回配置目录路径
+// 环境变量 CFTUNNEL_HOME 优先（系统服务以其他用户身份运行时指定）
 // 便携模式：程序同级目录存在 portable 文件时，使用程序所在目录
 // 普通模式：~/.cftunnel/
 func Dir() string {
 	dirOnce.Do(func() {
+		if v := os.Getenv("CFTUNNEL_HOME"); v != "" {
+			dirPath = v
+			return
+		}
 		if exe, err := os.Executable(); err == nil {
 			if real, err := filepath.EvalSymlinks(exe); err == nil {
 				exeDir := filepath.Dir(real)
@@ -117,22 +264,50 @@ func Path() string {
 	return filepath.Join(Dir(), "config.yml")
 }
 
+// Load 加载配置，并激活选中的上下文（见 SelectedProfile）
 func Load() (*Config, error) {
+	return LoadProfile("")
+}
+
+// LoadProfile 加载配置并激活指定上下文，name 为空时使用选中的上下文
+func LoadProfile(name string) (*Config, error) {
+	return load(name, false)
+}
+
+func load(name string, locked bool) (*Config, error) {
 	data, err := os.ReadFile(Path())
-	if err != nil {
-		if os.IsNotExist(err) {
-			cfg := &Config{Version: 1}
-			cfg.applyEnvOverrides()
-			return cfg, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	cfg := &Config{Version: CurrentVersion, locked: locked}
+	migrated := false
+	if err == nil {
+		if data, migrated, err = migrate(data); err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+		if err := cfg.decryptSecrets(); err != nil {
+			return nil, err
+		}
+		// 明文密码随升级一并保存为哈希
+		migrated = migrated || cfg.hasPlainPasswords()
+	}
+	if name == "" {
+		name = cfg.SelectedProfile()
+	}
+	if err := cfg.activate(name); err != nil {
 		return nil, err
 	}
+	// 升级结果立即持久化（在环境变量覆盖之前，避免写入临时值）
+	if migrated {
+		if err := cfg.Save(); err != nil {
+			return nil, err
+		}
+	}
 	cfg.applyEnvOverrides()
-	return &cfg, nil
+	return cfg, nil
 }
 
 // applyEnvOverrides 用环境变量覆盖配置（CI/CD 和 Docker 场景）
@@ -151,15 +326,82 @@ func (c *Config) applyEnvOverrides() {
 	}
 }
 
+// Save 原子写入配置文件；未持有锁时（非 Update 场景）自动加锁
 func (c *Config) Save() error {
-	if err := os.MkdirAll(Dir(), 0700); err != nil {
+	if !c.locked {
+		unlock, err := lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	if err := c.hashPasswords(); err != nil {
 		return err
 	}
-	data, err := yaml.Marshal(c)
+	out := c.persisted()
+	if c.Encrypted() {
+		var err error
+		if out, err = encryptedCopy(out); err != nil {
+			return err
+		}
+	}
+	data, err := yaml.Marshal(out)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(Path(), data, 0600)
+	if err := WriteFileAtomic(Path(), data, 0600); err != nil {
+		return err
+	}
+	if c.staleKeyring != nil {
+		keyringDelete(*c.staleKeyring)
+		c.staleKeyring = nil
+	}
+	return nil
+}
+
+// hashPasswords 将明文鉴权密码转换为哈希（兼容旧版配置）
+func (c *Config) hashPasswords() error {
+	for _, u := range c.authUsers() {
+		if u.Password == "" || passwd.IsHash(u.Password) {
+			continue
+		}
+		h, err := passwd.Hash(u.Password)
+		if err != nil {
+			return err
+		}
+		u.Password = h
+	}
+	return nil
+}
+
+// hasPlainPasswords 是否存在旧版配置遗留的明文鉴权密码（鉴权代理不再接受明文）
+func (c *Config) hasPlainPasswords() bool {
+	for _, u := range c.authUsers() {
+		if u.Password != "" && !passwd.IsHash(u.Password) {
+			return true
+		}
+	}
+	return false
+}
+
+// authUsers 返回所有上下文中的鉴权用户
+func (c *Config) authUsers() []*AuthUser {
+	var users []*AuthUser
+	collect := func(routes []RouteConfig) {
+		for i := range routes {
+			if a := routes[i].Auth; a != nil {
+				for j := range a.Users {
+					users = append(users, &a.Users[j])
+				}
+			}
+		}
+	}
+	collect(c.Routes)
+	collect(c.base.Routes)
+	for _, p := range c.Profiles {
+		collect(p.Routes)
+	}
+	return users
 }
 
 func (c *Config) FindRoute(name string) *RouteConfig {
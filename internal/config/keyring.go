@@ -0,0 +1,35 @@
+package config
+
+import "github.com/zalando/go-keyring"
+
+// 密钥环条目：服务名固定，账户名使用配置文件路径，区分不同配置目录（含便携模式）
+// 更换密钥时新旧主密钥按 id 分别存放，配置保存成功后才删除旧条目
+const keyringService = "cftunnel"
+
+func keyringAccount(id string) string {
+	if id == "" {
+		return Path() // 旧版配置没有 key_id
+	}
+	return Path() + "#" + id
+}
+
+func keyringGet(id string) (string, error) {
+	return keyring.Get(keyringService, keyringAccount(id))
+}
+
+func keyringSet(id, v string) error {
+	return keyring.Set(keyringService, keyringAccount(id), v)
+}
+
+func keyringDelete(id string) error {
+	return keyring.Delete(keyringService, keyringAccount(id))
+}
+
+// keyringProbe 写入并删除一个探测条目，确认密钥环服务可用
+func keyringProbe() error {
+	const probe = "probe"
+	if err := keyring.Set(keyringService, probe, probe); err != nil {
+		return err
+	}
+	return keyring.Delete(keyringService, probe)
+}
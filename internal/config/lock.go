@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lock 获取配置文件的跨进程咨询锁（config.yml.lock），返回释放函数
+// cftunnel-app 与脚本并发执行命令时，防止读-改-写相互覆盖
+func lock() (func(), error) {
+	unlock, err := LockFile(Path() + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("锁定配置文件失败: %w", err)
+	}
+	return unlock, nil
+}
+
+// LockFile 获取指定锁文件的跨进程独占锁（阻塞等待），返回释放函数
+func LockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// Update 在文件锁保护下加载配置、执行修改并保存（事务式读-改-写）
+// fn 返回错误时不保存；fn 内可多次调用 Save 持久化中间状态
+func Update(fn func(*Config) error) error {
+	return UpdateProfile("", fn)
+}
+
+// UpdateProfile 同 Update，但激活指定上下文，name 为空时使用选中的上下文
+func UpdateProfile(name string, fn func(*Config) error) error {
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := load(name, true)
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return cfg.Save()
+}
+
+// WriteFileAtomic 先写临时文件再重命名，避免写入中断导致文件损坏
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// migration 配置结构升级步骤，将版本 from 的原始 YAML 数据升级到 from+1
+type migration struct {
+	from  int
+	desc  string
+	apply func(raw map[string]any) error
+}
+
+// migrations 按版本顺序排列，新增结构变更时追加步骤
+var migrations = []migration{
+	{0, "补全 version 字段", func(raw map[string]any) error { return nil }},
+	{1, "引入多上下文 (profiles) 与敏感信息加密 (secrets)", func(raw map[string]any) error { return nil }},
+	{2, "路由鉴权 username/password 迁移为 users 列表", migrateAuthUsers},
+}
+
+// CurrentVersion 当前程序支持的配置结构版本
+var CurrentVersion = len(migrations)
+
+// migrate 检测配置版本并依次执行升级步骤，返回升级后的数据
+// migrated 为 true 时调用方需要持久化；旧文件会备份为 config.yml.v<旧版本>.bak
+func migrate(data []byte) (out []byte, migrated bool, err error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, false, err
+	}
+	if raw == nil {
+		raw = map[string]any{}
+	}
+
+	version := 0
+	if v, ok := raw["version"]; ok {
+		n, ok := v.(int)
+		if !ok {
+			return nil, false, fmt.Errorf("配置文件 version 字段无效: %v", v)
+		}
+		version = n
+	}
+	if version > CurrentVersion {
+		return nil, false, fmt.Errorf("配置文件版本 %d 高于当前程序支持的版本 %d，请先升级 cftunnel（cftunnel update）", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return data, false, nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", Path(), version)
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return nil, false, fmt.Errorf("备份旧配置失败: %w", err)
+	}
+	for _, m := range migrations[version:] {
+		if err := m.apply(raw); err != nil {
+			return nil, false, fmt.Errorf("配置升级 v%d → v%d 失败（%s）: %w", m.from, m.from+1, m.desc, err)
+		}
+		raw["version"] = m.from + 1
+	}
+	if out, err = yaml.Marshal(raw); err != nil {
+		return nil, false, err
+	}
+	fmt.Fprintf(os.Stderr, "配置已从 v%d 升级到 v%d，旧文件备份于 %s\n", version, CurrentVersion, backup)
+	return out, true, nil
+}
+
+// migrateAuthUsers 将 auth.username/password 迁移为 auth.users（顶层及全部上下文）
+func migrateAuthUsers(raw map[string]any) error {
+	convert := func(section map[string]any) {
+		routes, _ := section["routes"].([]any)
+		for _, r := range routes {
+			route, _ := r.(map[string]any)
+			auth, _ := route["auth"].(map[string]any)
+			if auth == nil {
+				continue
+			}
+			if user, ok := auth["username"]; ok {
+				auth["users"] = []any{map[string]any{"username": user, "password": auth["password"]}}
+				delete(auth, "username")
+				delete(auth, "password")
+			}
+		}
+	}
+	convert(raw)
+	profiles, _ := raw["profiles"].(map[string]any)
+	for _, p := range profiles {
+		if section, ok := p.(map[string]any); ok {
+			convert(section)
+		}
+	}
+	return nil
+}
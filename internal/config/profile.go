@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// DefaultProfile 默认上下文名称，对应 config.yml 顶层的 auth/tunnel/routes/relay
+const DefaultProfile = "default"
+
+// Profile 命名上下文：一套独立的 Cloudflare 账户、隧道、路由和中继配置
+type Profile struct {
+	Auth   AuthConfig    `yaml:"auth"`
+	Tunnel TunnelConfig  `yaml:"tunnel"`
+	Routes []RouteConfig `yaml:"routes"`
+	Relay  RelayConfig   `yaml:"relay,omitempty"`
+}
+
+// SelectedProfile 返回本次运行选中的上下文名称
+// 优先级：CFTUNNEL_PROFILE 环境变量（--profile 参数会写入该变量）> current_profile > default
+func (c *Config) SelectedProfile() string {
+	if v := os.Getenv("CFTUNNEL_PROFILE"); v != "" {
+		return v
+	}
+	if c.CurrentProfile != "" {
+		return c.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// ActiveProfile 返回当前加载到顶层字段的上下文名称
+func (c *Config) ActiveProfile() string {
+	if c.active == "" {
+		return DefaultProfile
+	}
+	return c.active
+}
+
+// ProfileNames 返回所有上下文名称，default 在首位
+func (c *Config) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	var rest []string
+	for name := range c.Profiles {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// HasProfile 检查上下文是否存在
+func (c *Config) HasProfile(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	_, ok := c.Profiles[name]
+	return ok
+}
+
+// FindProfile 返回上下文配置（当前激活的上下文返回顶层字段的快照）
+func (c *Config) FindProfile(name string) *Profile {
+	switch {
+	case name == c.ActiveProfile():
+		p := c.profile()
+		return &p
+	case name == DefaultProfile:
+		p := c.base
+		return &p
+	}
+	return c.Profiles[name]
+}
+
+// UseProfile 切换默认上下文（需调用 Save 持久化）
+func (c *Config) UseProfile(name string) error {
+	if !c.HasProfile(name) {
+		return fmt.Errorf("上下文 %s 不存在", name)
+	}
+	if name == DefaultProfile {
+		c.CurrentProfile = ""
+	} else {
+		c.CurrentProfile = name
+	}
+	return nil
+}
+
+// CreateProfile 新建空白上下文
+func (c *Config) CreateProfile(name string) error {
+	if name == "" {
+		return fmt.Errorf("上下文名称不能为空")
+	}
+	if c.HasProfile(name) {
+		return fmt.Errorf("上下文 %s 已存在", name)
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*Profile)
+	}
+	c.Profiles[name] = &Profile{}
+	return nil
+}
+
+// DeleteProfile 删除上下文（default 和当前激活的上下文不可删除）
+func (c *Config) DeleteProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("default 上下文不可删除")
+	}
+	if name == c.ActiveProfile() {
+		return fmt.Errorf("上下文 %s 正在使用中，请先切换到其他上下文", name)
+	}
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("上下文 %s 不存在", name)
+	}
+	delete(c.Profiles, name)
+	if c.CurrentProfile == name {
+		c.CurrentProfile = ""
+	}
+	return nil
+}
+
+// activate 将选中的上下文加载到顶层字段，default 配置暂存到 base
+func (c *Config) activate(name string) error {
+	c.active = name
+	c.base = c.profile()
+	if name == DefaultProfile {
+		return nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("上下文 %s 不存在，使用 cftunnel context list 查看", name)
+	}
+	c.setProfile(*p)
+	return nil
+}
+
+// persisted 返回写入文件的配置：激活上下文写回 profiles，顶层恢复为 default
+func (c *Config) persisted() *Config {
+	out := *c
+	if c.ActiveProfile() == DefaultProfile {
+		return &out
+	}
+	out.Profiles = make(map[string]*Profile, len(c.Profiles))
+	for name, p := range c.Profiles {
+		out.Profiles[name] = p
+	}
+	cur := c.profile()
+	out.Profiles[c.active] = &cur
+	out.setProfile(c.base)
+	return &out
+}
+
+func (c *Config) profile() Profile {
+	return Profile{Auth: c.Auth, Tunnel: c.Tunnel, Routes: c.Routes, Relay: c.Relay}
+}
+
+func (c *Config) setProfile(p Profile) {
+	c.Auth = p.Auth
+	c.Tunnel = p.Tunnel
+	c.Routes = p.Routes
+	c.Relay = p.Relay
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// 密钥来源
+const (
+	SecretsKeyring    = "keyring"    // 系统密钥环（Linux Secret Service / macOS 钥匙串 / Windows 凭据管理器）
+	SecretsPassphrase = "passphrase" // 口令派生（scrypt + AES-GCM）
+)
+
+const encPrefix = "enc:v1:"
+
+// checkPlaintext 用于校验主密钥是否正确的固定明文
+const checkPlaintext = "cftunnel"
+
+// SecretsConfig 敏感字段加密配置，Provider 为空表示明文存储
+type SecretsConfig struct {
+	Provider string `yaml:"provider,omitempty"`
+	Salt     string `yaml:"salt,omitempty"`   // passphrase 模式 scrypt 盐值 (hex)
+	Check    string `yaml:"check,omitempty"`  // 校验密文
+	KeyID    string `yaml:"key_id,omitempty"` // keyring 模式主密钥条目编号
+}
+
+// PassphrasePrompt 交互式读取口令，由 cmd 层注入；confirm 为 true 时表示设置新口令
+// 优先读取环境变量（系统服务等非交互场景），见 readPassphrase
+var PassphrasePrompt func(confirm bool) (string, error)
+
+var (
+	keyMu     sync.Mutex
+	cachedKey []byte
+)
+
+// Encrypted 返回敏感字段是否已加密存储
+func (c *Config) Encrypted() bool {
+	return c.Secrets.Provider != ""
+}
+
+// EnableEncryption 启用（或更换）加密方式并生成新的主密钥，调用 Save 后生效
+// keyring 模式的新主密钥写入新条目，旧条目保留到 Save 成功，保存失败时仍可用旧密钥解密
+func (c *Config) EnableEncryption(provider string) error {
+	key := make([]byte, 32)
+	var salt []byte
+	var keyID string
+	switch provider {
+	case SecretsKeyring:
+		rand.Read(key)
+		id := make([]byte, 4)
+		rand.Read(id)
+		keyID = hex.EncodeToString(id)
+		if err := keyringSet(keyID, hex.EncodeToString(key)); err != nil {
+			return fmt.Errorf("写入系统密钥环失败: %w", err)
+		}
+	case SecretsPassphrase:
+		pass, err := readPassphrase(true)
+		if err != nil {
+			return err
+		}
+		salt = make([]byte, 16)
+		rand.Read(salt)
+		if key, err = deriveKey(pass, salt); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("未知的密钥来源: %s（可选 keyring / passphrase）", provider)
+	}
+
+	check, err := encryptValue(key, checkPlaintext)
+	if err != nil {
+		return err
+	}
+	if c.Secrets.Provider == SecretsKeyring {
+		stale := c.Secrets.KeyID
+		c.staleKeyring = &stale
+	}
+	c.Secrets = SecretsConfig{Provider: provider, Salt: hex.EncodeToString(salt), Check: check, KeyID: keyID}
+	keyMu.Lock()
+	cachedKey = key
+	keyMu.Unlock()
+	return nil
+}
+
+// KeyringAvailable 检测系统密钥环是否可用
+func KeyringAvailable() bool {
+	return keyringProbe() == nil
+}
+
+// secretFields 返回所有敏感字段的指针（顶层和全部上下文）
+func (c *Config) secretFields() []*string {
+	var fields []*string
+	collect := func(auth *AuthConfig, tunnel *TunnelConfig, routes []RouteConfig, relay *RelayConfig) {
+		fields = append(fields, &auth.APIToken, &tunnel.Token, &relay.Token)
+		for i := range routes {
+			if a := routes[i].Auth; a != nil {
+				fields = append(fields, &a.SigningKey)
+				if a.OIDC != nil {
+					fields = append(fields, &a.OIDC.ClientSecret)
+				}
+				if a.Headers != nil {
+					fields = append(fields, &a.Headers.Secret)
+				}
+				for j := range a.Users {
+					fields = append(fields, &a.Users[j].Password, &a.Users[j].TOTPSecret)
+				}
+			}
+		}
+	}
+	collect(&c.Auth, &c.Tunnel, c.Routes, &c.Relay)
+	for _, p := range c.Profiles {
+		collect(&p.Auth, &p.Tunnel, p.Routes, &p.Relay)
+	}
+	return fields
+}
+
+// decryptSecrets 解密所有敏感字段（Load 时调用）
+func (c *Config) decryptSecrets() error {
+	if !c.Encrypted() {
+		return nil
+	}
+	key, err := c.masterKey()
+	if err != nil {
+		return err
+	}
+	for _, f := range c.secretFields() {
+		if !strings.HasPrefix(*f, encPrefix) {
+			continue // 手动编辑写入的明文，下次保存时加密
+		}
+		v, err := decryptValue(key, *f)
+		if err != nil {
+			return fmt.Errorf("解密配置失败: %w", err)
+		}
+		*f = v
+	}
+	return nil
+}
+
+// encryptedCopy 返回敏感字段已加密的深拷贝，不修改内存中的明文配置
+func encryptedCopy(c *Config) (*Config, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	out := &Config{}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	key, err := c.masterKey()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range out.secretFields() {
+		if *f == "" {
+			continue
+		}
+		if *f, err = encryptValue(key, *f); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// masterKey 获取主密钥并校验，进程内缓存
+func (c *Config) masterKey() ([]byte, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if cachedKey != nil {
+		return cachedKey, nil
+	}
+
+	var key []byte
+	switch c.Secrets.Provider {
+	case SecretsKeyring:
+		v, err := keyringGet(c.Secrets.KeyID)
+		if err != nil {
+			return nil, fmt.Errorf("读取系统密钥环失败: %w", err)
+		}
+		if key, err = hex.DecodeString(v); err != nil {
+			return nil, fmt.Errorf("密钥环中的主密钥无效: %w", err)
+		}
+	case SecretsPassphrase:
+		salt, err := hex.DecodeString(c.Secrets.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("secrets.salt 无效: %w", err)
+		}
+		pass, err := readPassphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		if key, err = deriveKey(pass, salt); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("未知的密钥来源: %s", c.Secrets.Provider)
+	}
+
+	if v, err := decryptValue(key, c.Secrets.Check); err != nil || v != checkPlaintext {
+		return nil, fmt.Errorf("主密钥校验失败（口令错误或密钥环数据已变更）")
+	}
+	cachedKey = key
+	return key, nil
+}
+
+// readPassphrase 读取口令：confirm 为 true 时读取新口令（CFTUNNEL_NEW_PASSPHRASE），否则读取现有口令（CFTUNNEL_PASSPHRASE）
+func readPassphrase(confirm bool) (string, error) {
+	env := "CFTUNNEL_PASSPHRASE"
+	if confirm {
+		env = "CFTUNNEL_NEW_PASSPHRASE"
+	}
+	if v := os.Getenv(env); v != "" {
+		return v, nil
+	}
+	if PassphrasePrompt == nil {
+		return "", fmt.Errorf("配置已加密，请设置 %s 环境变量", env)
+	}
+	pass, err := PassphrasePrompt(confirm)
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", fmt.Errorf("口令不能为空")
+	}
+	if !confirm {
+		// 传递给后台启动的 cftunnel run 子进程，避免重复输入
+		os.Setenv(env, pass)
+	}
+	return pass, nil
+}
+
+// deriveKey scrypt 派生 32 字节密钥（N=2^15, r=8, p=1）
+func deriveKey(pass string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(pass), salt, 1<<15, 8, 1, 32)
+}
+
+// encryptValue AES-256-GCM 加密，格式：enc:v1:base64(nonce|ciphertext)
+func encryptValue(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptValue(key []byte, v string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, encPrefix))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", fmt.Errorf("密文长度无效")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
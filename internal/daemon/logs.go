@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+)
+
+// LogFilePath 根据操作系统返回隧道日志路径
+func LogFilePath() string {
+	return logPath("cftunnel.log")
+}
+
+// AuthLogPath 返回鉴权失败日志路径（与隧道日志同目录）
+func AuthLogPath() string {
+	return logPath("cftunnel-auth.log")
+}
+
+// OpenAuthLog 以追加方式打开鉴权失败日志
+func OpenAuthLog() (*log.Logger, error) {
+	path := AuthLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return log.New(f, "", log.LstdFlags), nil
+}
+
+// AccessLogPath 返回访问日志路径（与隧道日志同目录）
+func AccessLogPath() string {
+	return logPath("cftunnel-access.log")
+}
+
+// OpenAccessLog 打开访问日志（按大小轮转），配置禁用时返回 nil
+func OpenAccessLog(cfg config.AccessLogConfig) (*authproxy.AccessLogger, error) {
+	if cfg.Disabled {
+		return nil, nil
+	}
+	f, err := openRotating(AccessLogPath(), int64(cfg.MaxSizeMBOrDefault())<<20, cfg.MaxBackupsOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	l, err := authproxy.NewAccessLogger(f, cfg.Format)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func logPath(name string) string {
+	// 便携模式：日志放在程序同级目录
+	if config.Portable() {
+		return filepath.Join(config.Dir(), name)
+	}
+	// 普通模式：按 OS 惯例
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library/Logs", name)
+	case "windows":
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "cftunnel", name)
+		}
+		return filepath.Join(home, ".cftunnel", name)
+	default:
+		return filepath.Join(home, ".local/share/cftunnel", name)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/qingchencloud/cftunnel/internal/config"
 )
@@ -16,26 +17,57 @@ func pidFilePath() string {
 	return filepath.Join(config.Dir(), "cloudflared.pid")
 }
 
-// Start 启动 cloudflared（token 模式）
-func Start(token string) error {
-	binPath, err := EnsureCloudflared()
-	if err != nil {
+// startTimeout 等待守护进程写入 PID 文件的最长时间
+const startTimeout = 15 * time.Second
+
+// Start 在后台启动守护进程（cftunnel run），由其托管 cloudflared 和鉴权代理。
+// 守护进程脱离当前终端运行，输出写入日志文件；持有启动锁直到其写入 PID 文件，
+// 避免并发执行的 up 各自启动一个守护进程
+func Start() error {
+	if _, err := EnsureCloudflared(); err != nil {
 		return err
 	}
+	unlock, err := config.LockFile(pidFilePath() + ".lock")
+	if err != nil {
+		return fmt.Errorf("获取启动锁失败: %w", err)
+	}
+	defer unlock()
 	if Running() {
 		return fmt.Errorf("cloudflared 已在运行")
 	}
 
-	cmd := exec.Command(binPath, "tunnel", "--protocol", "http2", "run", "--token", token)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取程序路径失败: %w", err)
+	}
+	logFile := LogFilePath()
+	os.MkdirAll(filepath.Dir(logFile), 0700)
+	out, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	defer out.Close()
+	cmd := exec.Command(exe, "run")
+	cmd.Stdout = out
+	cmd.Stderr = out
+	detach(cmd)
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("启动 cloudflared 失败: %w", err)
+		return fmt.Errorf("启动守护进程失败: %w", err)
 	}
 
-	os.MkdirAll(config.Dir(), 0700)
-	os.WriteFile(pidFilePath(), []byte(strconv.Itoa(cmd.Process.Pid)), 0600)
-	fmt.Printf("cloudflared 已启动 (PID: %d)\n", cmd.Process.Pid)
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	deadline := time.After(startTimeout)
+	for PID() != cmd.Process.Pid {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("守护进程启动后退出 (%v)，详见日志 %s", err, logFile)
+		case <-deadline:
+			return fmt.Errorf("守护进程 (PID: %d) 未在 %s 内就绪，详见日志 %s", cmd.Process.Pid, startTimeout, logFile)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	fmt.Printf("守护进程已启动 (PID: %d)，日志: %s\n", cmd.Process.Pid, logFile)
 	return nil
 }
 
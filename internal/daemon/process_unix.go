@@ -6,8 +6,14 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
+	"syscall"
 )
 
+// detach 守护进程进入新会话，脱离终端，关闭终端或 Ctrl+C 不会波及它
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
 // processRunning 检查进程是否存活（Unix: kill -0）
 func processRunning(pid int) bool {
 	return exec.Command("kill", "-0", strconv.Itoa(pid)).Run() == nil
@@ -6,8 +6,16 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
 )
 
+// detach 守护进程不继承控制台并使用新进程组，关闭窗口或 Ctrl+C 不会波及它
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS}
+}
+
 // processRunning 检查进程是否存活（Windows: tasklist）
 func processRunning(pid int) bool {
 	out, err := exec.Command("tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/NH").Output()
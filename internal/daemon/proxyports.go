@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+)
+
+// proxyPorts 守护进程启动的鉴权代理端口，CLI 推送 ingress 时据此把受保护的路由指向代理
+type proxyPorts struct {
+	TunnelID string         `json:"tunnel_id"`
+	Ports    map[string]int `json:"ports"` // 路由名称 → 代理端口
+}
+
+func proxyPortsPath() string {
+	return filepath.Join(config.Dir(), "proxy-ports.json")
+}
+
+// ProxyPortMap 返回代理的路由名称 → 监听端口
+func ProxyPortMap(proxies []*authproxy.Proxy) map[string]int {
+	ports := make(map[string]int, len(proxies))
+	for _, p := range proxies {
+		ports[p.Name()] = p.ListenPort()
+	}
+	return ports
+}
+
+func writeProxyPorts(tunnelID string, proxies []*authproxy.Proxy) error {
+	data, err := json.Marshal(proxyPorts{TunnelID: tunnelID, Ports: ProxyPortMap(proxies)})
+	if err != nil {
+		return err
+	}
+	return config.WriteFileAtomic(proxyPortsPath(), data, 0600)
+}
+
+// RunningProxyPorts 返回运行中的守护进程为该隧道启动的代理端口，未运行时返回 nil
+func RunningProxyPorts(tunnelID string) map[string]int {
+	if !Running() {
+		return nil
+	}
+	data, err := os.ReadFile(proxyPortsPath())
+	if err != nil {
+		return nil
+	}
+	var pp proxyPorts
+	if json.Unmarshal(data, &pp) != nil || pp.TunnelID != tunnelID {
+		return nil
+	}
+	return pp.Ports
+}
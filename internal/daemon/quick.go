@@ -8,11 +8,14 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/qingchencloud/cftunnel/internal/authproxy"
 	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/inspector"
+	"github.com/qingchencloud/cftunnel/internal/passwd"
 )
 
 // quickConfigPath 返回 quick 模式专用的空配置文件路径
@@ -26,8 +29,14 @@ func quickConfigPath() string {
 	return p
 }
 
+// QuickOptions quick 模式附加选项
+type QuickOptions struct {
+	Inspect     bool // 启用本地请求检查器
+	InspectPort int  // 检查器 Web UI 端口，默认 4040
+}
+
 // StartQuick 启动免域名模式（前台运行，Ctrl+C 退出）
-func StartQuick(port string) error {
+func StartQuick(port string, opts QuickOptions) error {
 	binPath, err := EnsureCloudflared()
 	if err != nil {
 		return err
@@ -36,6 +45,35 @@ func StartQuick(port string) error {
 		return fmt.Errorf("cloudflared 已在运行，请先执行 cftunnel down")
 	}
 
+	port, stop, err := startInspector(port, opts)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	return runQuickTunnel(binPath, port)
+}
+
+// startInspector 按需在本地服务前启动请求检查器，返回新的转发端口
+func startInspector(port string, opts QuickOptions) (string, func(), error) {
+	if !opts.Inspect {
+		return port, func() {}, nil
+	}
+	insp, err := inspector.New(port, inspector.DefaultCapacity)
+	if err != nil {
+		return "", nil, fmt.Errorf("启动请求检查器失败: %w", err)
+	}
+	uiURL, err := insp.Start(opts.InspectPort)
+	if err != nil {
+		insp.Stop()
+		return "", nil, fmt.Errorf("启动请求检查器失败: %w", err)
+	}
+	fmt.Printf("请求检查器: %s\n", uiURL)
+	return strconv.Itoa(insp.ListenPort()), func() { insp.Stop() }, nil
+}
+
+// runQuickTunnel 启动指向本地端口的临时隧道，等待退出
+func runQuickTunnel(binPath, port string) error {
 	// 显式指定空配置文件，防止 cloudflared 读取用户已有的 ~/.cloudflared/config.yml
 	// 避免残留的 tunnel: 字段触发 UUID 解析失败 (issue #13)
 	cfgPath := quickConfigPath()
@@ -98,8 +136,9 @@ func extractURL(line string) string {
 	return ""
 }
 
-// StartQuickWithAuth 启动带鉴权代理的免域名模式
-func StartQuickWithAuth(port, username, password string) error {
+// StartQuickWithAuth 启动带鉴权代理的免域名模式，publicPaths 中的路径无需登录
+// 启用检查器时链路为 cloudflared → 鉴权代理 → 检查器 → 本地服务
+func StartQuickWithAuth(port, username, password string, publicPaths []string, opts QuickOptions) error {
 	binPath, err := EnsureCloudflared()
 	if err != nil {
 		return err
@@ -108,13 +147,31 @@ func StartQuickWithAuth(port, username, password string) error {
 		return fmt.Errorf("cloudflared 已在运行，请先执行 cftunnel down")
 	}
 
+	// 鉴权代理只接受密码哈希
+	hash, err := passwd.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	upstream, stop, err := startInspector(port, opts)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
 	// 启动鉴权代理
+	authLog, err := OpenAuthLog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 无法打开鉴权日志: %v\n", err)
+	}
 	proxy, err := authproxy.New(authproxy.Config{
-		Username:   username,
-		Password:   password,
-		TargetPort: port,
+		Name:        "quick",
+		AuthLog:     authLog,
+		Users:       map[string]string{username: hash},
+		PublicPaths: publicPaths,
+		TargetPort:  upstream,
 		SigningKey:  authproxy.RandomKey(),
-		CookieTTL:  24 * time.Hour,
+		CookieTTL:   24 * time.Hour,
 	})
 	if err != nil {
 		return fmt.Errorf("启动鉴权代理失败: %w", err)
@@ -128,35 +185,5 @@ func StartQuickWithAuth(port, username, password string) error {
 	fmt.Printf("鉴权代理已启动 127.0.0.1:%s → 127.0.0.1:%s\n", proxyPort, port)
 
 	// cloudflared 指向代理端口（同样隔离配置文件）
-	cfgPath := quickConfigPath()
-	cmd := exec.Command(binPath, "tunnel", "--config", cfgPath, "--url", "http://localhost:"+proxyPort)
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return err
-	}
-	cmd.Stdout = os.Stdout
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("启动 cloudflared 失败: %w", err)
-	}
-
-	go scanForURL(stderr)
-
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt)
-
-	done := make(chan error, 1)
-	go func() { done <- cmd.Wait() }()
-
-	select {
-	case <-sig:
-		stopChildProcess(cmd)
-		<-done
-	case err := <-done:
-		if err != nil {
-			return fmt.Errorf("cloudflared 异常退出: %w", err)
-		}
-	}
-	return nil
+	return runQuickTunnel(binPath, proxyPort)
 }
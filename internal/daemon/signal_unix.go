@@ -3,10 +3,14 @@
 package daemon
 
 import (
+	"os"
 	"os/exec"
 	"syscall"
 )
 
+// shutdownSignals 守护进程需要处理的退出信号
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
 // stopChildProcess 优雅终止子进程（Unix: SIGINT）
 func stopChildProcess(cmd *exec.Cmd) {
 	cmd.Process.Signal(syscall.SIGINT)
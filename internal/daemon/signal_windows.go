@@ -3,10 +3,14 @@
 package daemon
 
 import (
+	"os"
 	"os/exec"
 	"strconv"
 )
 
+// shutdownSignals 守护进程需要处理的退出信号
+var shutdownSignals = []os.Signal{os.Interrupt}
+
 // stopChildProcess 优雅终止子进程（Windows: taskkill 发送关闭信号）
 func stopChildProcess(cmd *exec.Cmd) {
 	exec.Command("taskkill", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
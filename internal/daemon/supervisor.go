@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+)
+
+const (
+	restartBackoffMin = time.Second
+	restartBackoffMax = time.Minute
+	// 运行超过该时长视为稳定，退避时间重置
+	restartStableAfter = 2 * time.Minute
+	childStopTimeout   = 10 * time.Second
+)
+
+// Supervisor 前台守护进程：持有 cloudflared 子进程和所有鉴权代理
+// cloudflared 异常退出时按指数退避自动重启，收到退出信号后依次关闭
+type Supervisor struct {
+	token    string
+	tunnelID string
+	proxies  []*authproxy.Proxy
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSupervisor 创建守护进程实例，proxies 为已启动的鉴权代理
+func NewSupervisor(token, tunnelID string, proxies []*authproxy.Proxy) *Supervisor {
+	return &Supervisor{
+		token:    token,
+		tunnelID: tunnelID,
+		proxies:  proxies,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Shutdown 请求守护进程退出（供 Windows 服务控制等非信号场景使用）
+func (s *Supervisor) Shutdown() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Run 阻塞运行，直到收到退出信号或调用 Shutdown
+func (s *Supervisor) Run() error {
+	defer s.stopProxies()
+	binPath, err := EnsureCloudflared()
+	if err != nil {
+		return err
+	}
+	if Running() {
+		return fmt.Errorf("cloudflared 已在运行，请先执行 cftunnel down")
+	}
+
+	// 记录守护进程自身 PID，down/status 通过它管理整棵进程树
+	os.MkdirAll(config.Dir(), 0700)
+	os.WriteFile(pidFilePath(), []byte(strconv.Itoa(os.Getpid())), 0600)
+	defer os.Remove(pidFilePath())
+	// 记录代理端口，运行期间其他命令推送 ingress 时沿用，避免受保护的路由直连源站
+	if err := writeProxyPorts(s.tunnelID, s.proxies); err != nil {
+		fmt.Printf("警告: 记录鉴权代理端口失败: %v\n", err)
+	}
+	defer os.Remove(proxyPortsPath())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, shutdownSignals...)
+	defer signal.Stop(sig)
+
+	backoff := restartBackoffMin
+	for {
+		// token 通过 TUNNEL_TOKEN 环境变量传递，避免出现在 ps 可见的命令行参数中
+		cmd := exec.Command(binPath, "tunnel", "--protocol", "http2", "run")
+		cmd.Env = append(os.Environ(), "TUNNEL_TOKEN="+s.token)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("启动 cloudflared 失败: %w", err)
+		}
+		fmt.Printf("cloudflared 已启动 (PID: %d)\n", cmd.Process.Pid)
+		started := time.Now()
+
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+
+		select {
+		case got := <-sig:
+			fmt.Printf("收到信号 %v，正在停止...\n", got)
+			stopChild(cmd, done)
+			return nil
+		case <-s.stop:
+			fmt.Println("正在停止...")
+			stopChild(cmd, done)
+			return nil
+		case err := <-done:
+			if time.Since(started) >= restartStableAfter {
+				backoff = restartBackoffMin
+			}
+			fmt.Printf("cloudflared 异常退出: %v，%s 后重启\n", err, backoff)
+		}
+
+		select {
+		case got := <-sig:
+			fmt.Printf("收到信号 %v，正在停止...\n", got)
+			return nil
+		case <-s.stop:
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > restartBackoffMax {
+			backoff = restartBackoffMax
+		}
+	}
+}
+
+// stopProxies 按启动的逆序关闭鉴权代理
+func (s *Supervisor) stopProxies() {
+	for i := len(s.proxies) - 1; i >= 0; i-- {
+		s.proxies[i].Stop()
+	}
+}
+
+// stopChild 优雅终止 cloudflared，超时后强制结束
+func stopChild(cmd *exec.Cmd, done <-chan error) {
+	stopChildProcess(cmd)
+	select {
+	case <-done:
+	case <-time.After(childStopTimeout):
+		cmd.Process.Kill()
+		<-done
+	}
+}
@@ -0,0 +1,260 @@
+package inspector
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//go:embed ui.html
+var uiHTML []byte
+
+// csrfHeader 修改类 API 必须携带的请求头（跨站页面无法在不触发预检的情况下设置）
+const csrfHeader = "X-Cftunnel-Inspector"
+
+// summary 请求列表项
+type summary struct {
+	ID           int64     `json:"id"`
+	Time         time.Time `json:"time"`
+	DurationMS   float64   `json:"duration_ms"`
+	Method       string    `json:"method"`
+	URI          string    `json:"uri"`
+	Host         string    `json:"host"`
+	ClientIP     string    `json:"client_ip"`
+	Status       int       `json:"status"`
+	ReplayOf     int64     `json:"replay_of,omitempty"`
+	RequestSize  int       `json:"request_size"`
+	ResponseSize int       `json:"response_size"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// detail 请求详情
+type detail struct {
+	summary
+	RequestHeader  http.Header `json:"request_header"`
+	RequestBody    body        `json:"request_body"`
+	ResponseHeader http.Header `json:"response_header"`
+	ResponseBody   body        `json:"response_body"`
+}
+
+// body 文本内容直接返回，二进制内容以 base64 返回
+type body struct {
+	Text      string `json:"text,omitempty"`
+	Base64    string `json:"base64,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func newSummary(ex *Exchange) summary {
+	return summary{
+		ID:           ex.ID,
+		Time:         ex.Time,
+		DurationMS:   float64(ex.Duration.Microseconds()) / 1000,
+		Method:       ex.Method,
+		URI:          ex.URI,
+		Host:         ex.Host,
+		ClientIP:     ex.ClientIP,
+		Status:       ex.Status,
+		ReplayOf:     ex.ReplayOf,
+		RequestSize:  len(ex.RequestBody),
+		ResponseSize: len(ex.ResponseBody),
+		Error:        ex.Error,
+	}
+}
+
+func newDetail(ex *Exchange) detail {
+	return detail{
+		summary:        newSummary(ex),
+		RequestHeader:  ex.RequestHeader,
+		RequestBody:    newBody(ex.RequestBody, ex.RequestHeader, ex.RequestTruncated),
+		ResponseHeader: ex.ResponseHeader,
+		ResponseBody:   newBody(ex.ResponseBody, ex.ResponseHeader, ex.ResponseTruncated),
+	}
+}
+
+// newBody 按需解压 gzip 内容，便于在 UI 中查看
+func newBody(data []byte, h http.Header, truncated bool) body {
+	if !truncated && strings.EqualFold(h.Get("Content-Encoding"), "gzip") {
+		if zr, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+			if plain, err := io.ReadAll(io.LimitReader(zr, maxBody)); err == nil {
+				data = plain
+			}
+		}
+	}
+	b := body{Truncated: truncated}
+	if utf8.Valid(data) {
+		b.Text = string(data)
+	} else {
+		b.Base64 = base64.StdEncoding.EncodeToString(data)
+	}
+	return b
+}
+
+// uiHandler Web UI 与 JSON API
+func (i *Inspector) uiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(uiHTML)
+	})
+	mux.HandleFunc("GET /api/requests", i.handleList)
+	mux.HandleFunc("DELETE /api/requests", func(w http.ResponseWriter, r *http.Request) {
+		i.clear()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /api/requests/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ex := i.lookup(w, r)
+		if ex != nil {
+			writeJSON(w, http.StatusOK, newDetail(ex))
+		}
+	})
+	mux.HandleFunc("POST /api/requests/{id}/replay", func(w http.ResponseWriter, r *http.Request) {
+		ex := i.lookup(w, r)
+		if ex == nil {
+			return
+		}
+		if ex.RequestTruncated {
+			writeError(w, http.StatusConflict, "请求体超过记录上限已被截断，无法重放")
+			return
+		}
+		writeJSON(w, http.StatusOK, newDetail(i.replay(ex)))
+	})
+	return localOnly(mux)
+}
+
+// handleList 列出请求，支持 method、status（如 404、5xx）、q（匹配 URI 和请求体）过滤
+func (i *Inspector) handleList(w http.ResponseWriter, r *http.Request) {
+	method := strings.ToUpper(r.URL.Query().Get("method"))
+	status := strings.ToLower(r.URL.Query().Get("status"))
+	q := strings.ToLower(r.URL.Query().Get("q"))
+
+	out := []summary{}
+	for _, ex := range i.list() {
+		if method != "" && ex.Method != method {
+			continue
+		}
+		if status != "" && !matchStatus(status, ex.Status) {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(ex.URI), q) &&
+			!strings.Contains(strings.ToLower(string(ex.RequestBody)), q) {
+			continue
+		}
+		out = append(out, newSummary(ex))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// matchStatus 匹配状态码，支持 404 或 4xx 形式
+func matchStatus(pattern string, status int) bool {
+	code := strconv.Itoa(status)
+	if len(pattern) == 3 && strings.HasSuffix(pattern, "xx") {
+		return code[:1] == pattern[:1]
+	}
+	return code == pattern
+}
+
+// lookup 解析路径中的 ID 并查找记录，不存在时写入 404
+func (i *Inspector) lookup(w http.ResponseWriter, r *http.Request) *Exchange {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err == nil {
+		if ex := i.get(id); ex != nil {
+			return ex
+		}
+	}
+	writeError(w, http.StatusNotFound, "记录不存在或已被覆盖")
+	return nil
+}
+
+// hopHeaders 重放时不复制的逐跳请求头
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length",
+}
+
+var replayClient = &http.Client{
+	Timeout: 30 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// replay 将记录的请求重新发送到本地服务，结果作为新记录保存
+func (i *Inspector) replay(orig *Exchange) *Exchange {
+	ex := &Exchange{
+		Time:          time.Now(),
+		Method:        orig.Method,
+		URI:           orig.URI,
+		Host:          orig.Host,
+		ClientIP:      "127.0.0.1",
+		ReplayOf:      orig.ID,
+		RequestHeader: orig.RequestHeader.Clone(),
+		RequestBody:   orig.RequestBody,
+	}
+	for _, h := range hopHeaders {
+		ex.RequestHeader.Del(h)
+	}
+	defer i.add(ex)
+
+	req, err := http.NewRequest(ex.Method, i.target.String()+ex.URI, bytes.NewReader(ex.RequestBody))
+	if err != nil {
+		ex.Error = err.Error()
+		return ex
+	}
+	req.Header = ex.RequestHeader.Clone()
+	req.Host = ex.Host
+	resp, err := replayClient.Do(req)
+	ex.Duration = time.Since(ex.Time)
+	if err != nil {
+		ex.Error = err.Error()
+		return ex
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+	ex.Duration = time.Since(ex.Time)
+	ex.Status = resp.StatusCode
+	ex.ResponseHeader = resp.Header
+	if len(data) > maxBody {
+		data, ex.ResponseTruncated = data[:maxBody], true
+	}
+	ex.ResponseBody = data
+	return ex
+}
+
+// localOnly 仅接受以回环地址访问的请求（防御 DNS 重绑定），修改类请求需携带 csrfHeader
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("不允许通过 %s 访问", r.Host))
+			return
+		}
+		if r.Method != http.MethodGet && r.Header.Get(csrfHeader) == "" {
+			writeError(w, http.StatusForbidden, "缺少 "+csrfHeader+" 请求头")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
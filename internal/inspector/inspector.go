@@ -0,0 +1,230 @@
+// Package inspector 本地请求检查器：记录经过隧道的请求 / 响应，提供 Web UI、JSON API 和重放
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+)
+
+const (
+	DefaultCapacity = 200       // 默认保留的请求数
+	DefaultUIPort   = 4040      // 默认 Web UI 端口
+	maxBody         = 256 << 10 // 单个请求 / 响应体最多记录 256 KiB
+)
+
+// Exchange 一次请求与响应
+type Exchange struct {
+	ID                int64
+	Time              time.Time
+	Duration          time.Duration
+	Method            string
+	URI               string // 路径 + 查询参数
+	Host              string
+	ClientIP          string
+	ReplayOf          int64 // 重放来源的记录 ID
+	RequestHeader     http.Header
+	RequestBody       []byte
+	RequestTruncated  bool
+	Status            int
+	ResponseHeader    http.Header
+	ResponseBody      []byte
+	ResponseTruncated bool
+	Error             string
+}
+
+// Inspector 记录请求的反向代理，位于 cloudflared（或鉴权代理）与本地服务之间
+type Inspector struct {
+	target   *url.URL
+	listener net.Listener
+	server   *http.Server
+	ui       *http.Server
+	uiLn     net.Listener
+	reverse  *httputil.ReverseProxy
+
+	mu     sync.Mutex
+	ring   []*Exchange
+	next   int // 下一个写入位置
+	lastID int64
+}
+
+// New 创建检查器，代理监听自动探测的本地端口并转发到 targetPort
+func New(targetPort string, capacity int) (*Inspector, error) {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	port, _ := strconv.Atoi(targetPort)
+	ln, err := authproxy.FindAvailableListener(port + 1)
+	if err != nil {
+		return nil, err
+	}
+	target, _ := url.Parse("http://127.0.0.1:" + targetPort)
+	i := &Inspector{
+		target:   target,
+		listener: ln,
+		reverse:  httputil.NewSingleHostReverseProxy(target),
+		ring:     make([]*Exchange, capacity),
+	}
+	i.reverse.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, "本地服务不可用: "+err.Error(), http.StatusBadGateway)
+	}
+	i.server = &http.Server{Handler: http.HandlerFunc(i.serveProxy)}
+	return i, nil
+}
+
+// ListenPort 返回代理实际监听的端口
+func (i *Inspector) ListenPort() int {
+	return i.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Start 非阻塞启动代理和 Web UI（仅监听 127.0.0.1，端口被占用时顺延），返回 UI 地址
+func (i *Inspector) Start(uiPort int) (string, error) {
+	if uiPort <= 0 {
+		uiPort = DefaultUIPort
+	}
+	ln, err := authproxy.FindAvailableListener(uiPort)
+	if err != nil {
+		return "", err
+	}
+	i.uiLn = ln
+	i.ui = &http.Server{Handler: i.uiHandler()}
+	go i.server.Serve(i.listener)
+	go i.ui.Serve(ln)
+	return "http://" + ln.Addr().String(), nil
+}
+
+// Stop 关闭代理和 Web UI
+func (i *Inspector) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if i.ui != nil {
+		i.ui.Shutdown(ctx)
+	}
+	return i.server.Shutdown(ctx)
+}
+
+// serveProxy 记录请求并转发到本地服务
+func (i *Inspector) serveProxy(w http.ResponseWriter, r *http.Request) {
+	ex := &Exchange{
+		Time:          time.Now(),
+		Method:        r.Method,
+		URI:           r.URL.RequestURI(),
+		Host:          r.Host,
+		ClientIP:      authproxy.ClientIP(r),
+		RequestHeader: r.Header.Clone(),
+	}
+	if r.Body != nil {
+		ex.RequestBody, ex.RequestTruncated = peekBody(r)
+	}
+
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	i.reverse.ServeHTTP(rec, r)
+
+	ex.Duration = time.Since(ex.Time)
+	ex.Status = rec.status
+	ex.ResponseHeader = rec.Header().Clone()
+	ex.ResponseBody = rec.body.Bytes()
+	ex.ResponseTruncated = rec.truncated
+	i.add(ex)
+}
+
+// add 写入环形缓冲区，满时覆盖最旧的记录
+func (i *Inspector) add(ex *Exchange) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastID++
+	ex.ID = i.lastID
+	i.ring[i.next] = ex
+	i.next = (i.next + 1) % len(i.ring)
+}
+
+// list 返回全部记录，最新的在前
+func (i *Inspector) list() []*Exchange {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	out := make([]*Exchange, 0, len(i.ring))
+	for n := 1; n <= len(i.ring); n++ {
+		ex := i.ring[(i.next-n+len(i.ring))%len(i.ring)]
+		if ex == nil {
+			break
+		}
+		out = append(out, ex)
+	}
+	return out
+}
+
+// get 按 ID 查找记录
+func (i *Inspector) get(id int64) *Exchange {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, ex := range i.ring {
+		if ex != nil && ex.ID == id {
+			return ex
+		}
+	}
+	return nil
+}
+
+// clear 清空记录
+func (i *Inspector) clear() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.ring = make([]*Exchange, len(i.ring))
+	i.next = 0
+}
+
+// peekBody 读取请求体前 maxBody 字节用于记录，并还原请求体供转发
+func peekBody(r *http.Request) ([]byte, bool) {
+	buf, _ := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if len(buf) > maxBody {
+		return buf[:maxBody], true
+	}
+	return buf, false
+}
+
+// recorder 记录响应状态码和响应体前 maxBody 字节
+type recorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (r *recorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	room := maxBody - r.body.Len()
+	if len(p) > room {
+		r.truncated = true
+	}
+	if room > 0 {
+		r.body.Write(p[:min(room, len(p))])
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap 供 http.ResponseController 使用（Flush、WebSocket Hijack）
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush 支持 SSE 等流式响应
+func (r *recorder) Flush() {
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
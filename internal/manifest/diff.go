@@ -0,0 +1,42 @@
+package manifest
+
+// diffLines 基于最长公共子序列的逐行对比，仅返回变更行（"- " 删除，"+ " 新增）
+func diffLines(before, after []string) []string {
+	n, m := len(before), len(after)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case before[i] == after[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+before[i])
+			i++
+		default:
+			out = append(out, "+ "+after[j])
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		out = append(out, "- "+before[i])
+	}
+	for ; j < m; j++ {
+		out = append(out, "+ "+after[j])
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/qingchencloud/cftunnel/internal/authproxy"
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest 声明式配置清单，描述隧道、Cloud 路由（含鉴权）和中继规则的期望状态
+type Manifest struct {
+	Tunnel string              `yaml:"tunnel"`
+	Routes []Route             `yaml:"routes"`
+	Relay  *config.RelayConfig `yaml:"relay,omitempty"` // 省略时不管理中继配置
+}
+
+// Route 期望的 Cloud 路由
+type Route struct {
+	Name      string             `yaml:"name"`
+	Hostname  string             `yaml:"hostname"`
+	Service   string             `yaml:"service,omitempty"`
+	Port      int                `yaml:"port,omitempty"` // service 的简写，等价于 http://localhost:<port>
+	Auth      *Auth              `yaml:"auth,omitempty"`
+	Access    *config.AccessAuth `yaml:"access,omitempty"` // Cloudflare Access JWT 校验
+	IPFilter  *config.IPFilter   `yaml:"ip_filter,omitempty"`
+	AccessLog bool               `yaml:"access_log,omitempty"` // 无鉴权的路由也记录访问日志
+}
+
+// Auth 期望的鉴权配置，username/password 为单用户简写，与 users 合并
+type Auth struct {
+	Username     string                  `yaml:"username,omitempty"`
+	Password     string                  `yaml:"password,omitempty"`
+	Users        []User                  `yaml:"users,omitempty"`
+	HtpasswdFile string                  `yaml:"htpasswd_file,omitempty"`
+	OIDC         *config.OIDCAuth        `yaml:"oidc,omitempty"`
+	AnonymousWS  bool                    `yaml:"allow_anonymous_websocket,omitempty"`
+	PublicPaths  []string                `yaml:"public_paths,omitempty"`
+	CookieTTL    int                     `yaml:"cookie_ttl,omitempty"`
+	PortalDomain string                  `yaml:"portal_domain,omitempty"`    // 作为 SSO 门户时的父域名
+	SSO          string                  `yaml:"sso,omitempty"`              // 通过指定的门户路由登录
+	Headers      *config.IdentityHeaders `yaml:"identity_headers,omitempty"` // 转发给后端的身份头
+	LoginPage    *config.LoginPage       `yaml:"login_page,omitempty"`       // 自定义登录页
+}
+
+// User 期望的鉴权用户（明文密码，应用时转换为哈希）
+type User struct {
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Groups   []string `yaml:"groups,omitempty"`
+}
+
+// cookieTTL 返回生效的 Cookie 有效期（秒），默认值与 config.AuthProxy 一致
+func (a *Auth) cookieTTL() int {
+	return (&config.AuthProxy{CookieTTL: a.CookieTTL}).CookieTTLOrDefault()
+}
+
+// envRef 匹配 ${VAR} 形式的环境变量引用，便于清单入库时不写明文密钥
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Load 读取清单文件，展开 ${VAR} 环境变量引用并校验
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	data = envRef.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name := string(envRef.FindSubmatch(ref)[1])
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return []byte(v)
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("清单引用的环境变量未设置: %v", missing)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("解析清单失败: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// normalize 将 username/password 简写并入 users 并校验
+func (a *Auth) normalize() error {
+	if a.Username != "" || a.Password != "" {
+		a.Users = append([]User{{Username: a.Username, Password: a.Password}}, a.Users...)
+		a.Username, a.Password = "", ""
+	}
+	for _, pattern := range a.PublicPaths {
+		if err := authproxy.ValidatePublicPath(pattern); err != nil {
+			return err
+		}
+	}
+	if h := a.Headers; h != nil {
+		for _, name := range []string{h.User, h.Email, h.Groups} {
+			if name == "" {
+				continue
+			}
+			if err := authproxy.ValidateHeaderName(name); err != nil {
+				return fmt.Errorf("identity_headers: %w", err)
+			}
+		}
+	}
+	if a.LoginPage != nil && a.LoginPage.Template != "" {
+		if err := authproxy.ValidateLoginTemplate(a.LoginPage.Template); err != nil {
+			return err
+		}
+	}
+	if a.SSO != "" && a.PortalDomain != "" {
+		return fmt.Errorf("sso 不能与 portal_domain 同时使用")
+	}
+	if a.OIDC != nil {
+		switch {
+		case len(a.Users) > 0 || a.HtpasswdFile != "":
+			return fmt.Errorf("oidc 不能与 users / htpasswd_file 同时使用")
+		case a.OIDC.Issuer == "" || a.OIDC.ClientID == "":
+			return fmt.Errorf("oidc 缺少 issuer 或 client_id")
+		case len(a.OIDC.AllowedEmails) == 0 && len(a.OIDC.AllowedDomains) == 0:
+			return fmt.Errorf("oidc 需要配置 allowed_emails 或 allowed_domains")
+		}
+		return nil
+	}
+	if len(a.Users) == 0 && a.HtpasswdFile == "" {
+		if a.SSO != "" {
+			return nil
+		}
+		return fmt.Errorf("至少需要一个用户、htpasswd_file、oidc 或 sso")
+	}
+	seen := make(map[string]bool)
+	for _, u := range a.Users {
+		if u.Username == "" || u.Password == "" {
+			return fmt.Errorf("用户名和密码不能为空")
+		}
+		if seen[u.Username] {
+			return fmt.Errorf("用户 %s 重复定义", u.Username)
+		}
+		seen[u.Username] = true
+	}
+	return nil
+}
+
+func (m *Manifest) validate() error {
+	seen := make(map[string]bool)
+	for i := range m.Routes {
+		r := &m.Routes[i]
+		if r.Name == "" || r.Hostname == "" {
+			return fmt.Errorf("第 %d 条路由缺少 name 或 hostname", i+1)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("路由 %s 重复定义", r.Name)
+		}
+		seen[r.Name] = true
+		if r.Service == "" && r.Port > 0 {
+			r.Service = fmt.Sprintf("http://localhost:%d", r.Port)
+		}
+		if r.Service == "" {
+			return fmt.Errorf("路由 %s 缺少 service 或 port", r.Name)
+		}
+		if r.Access != nil && (r.Access.TeamDomain == "" || r.Access.AUD == "") {
+			return fmt.Errorf("路由 %s 的 access 缺少 team_domain 或 aud", r.Name)
+		}
+		if f := r.IPFilter; f != nil {
+			for _, s := range append(append([]string{}, f.Allow...), f.Deny...) {
+				if _, err := authproxy.ParsePrefix(s); err != nil {
+					return fmt.Errorf("路由 %s 的 ip_filter 配置无效: %w", r.Name, err)
+				}
+			}
+		}
+		if r.Auth != nil {
+			if err := r.Auth.normalize(); err != nil {
+				return fmt.Errorf("路由 %s 的 auth 配置无效: %w", r.Name, err)
+			}
+		}
+	}
+	if err := m.validateSSO(); err != nil {
+		return err
+	}
+	if m.Relay != nil {
+		seen = make(map[string]bool)
+		for i := range m.Relay.Rules {
+			r := &m.Relay.Rules[i]
+			if r.Proto == "" {
+				r.Proto = "tcp"
+			}
+			if r.Name == "" || r.LocalPort == 0 {
+				return fmt.Errorf("中继规则缺少 name 或 local_port")
+			}
+			if seen[r.Name] {
+				return fmt.Errorf("中继规则 %s 重复定义", r.Name)
+			}
+			seen[r.Name] = true
+		}
+	}
+	return nil
+}
+
+// validateSSO 校验 sso 引用的门户存在于清单中且路由域名位于门户父域名下
+func (m *Manifest) validateSSO() error {
+	for _, r := range m.Routes {
+		if r.Auth == nil || r.Auth.SSO == "" {
+			continue
+		}
+		var portal *Route
+		for i := range m.Routes {
+			if m.Routes[i].Name == r.Auth.SSO {
+				portal = &m.Routes[i]
+			}
+		}
+		switch {
+		case portal == nil || portal.Auth == nil || portal.Auth.PortalDomain == "":
+			return fmt.Errorf("路由 %s 的 sso 门户 %s 不存在或未设置 portal_domain", r.Name, r.Auth.SSO)
+		case !authproxy.InDomain(r.Hostname, portal.Auth.PortalDomain):
+			return fmt.Errorf("路由 %s 的域名 %s 不在门户父域名 %s 下", r.Name, r.Hostname, portal.Auth.PortalDomain)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+	"github.com/qingchencloud/cftunnel/internal/passwd"
+	"github.com/qingchencloud/cftunnel/internal/relay"
+)
+
+// Action 变更类型
+type Action string
+
+const (
+	Create Action = "create"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// RouteChange 单条路由的变更
+type RouteChange struct {
+	Action  Action
+	Name    string
+	Current *config.RouteConfig // Create 时为 nil
+	Desired *Route              // Delete 时为 nil
+}
+
+// HostnameChanged 返回是否需要变更 DNS 记录（新建、删除或域名变化）
+func (c *RouteChange) HostnameChanged() bool {
+	return c.Current == nil || c.Desired == nil || c.Current.Hostname != c.Desired.Hostname
+}
+
+// Plan 清单与当前配置的差异
+type Plan struct {
+	CreateTunnel string // 需要新建的隧道名称
+	Routes       []RouteChange
+	Ingress      []string // ingress 差异行（+/-）
+	Relay        *config.RelayConfig
+	Frpc         []string // frpc.toml 差异行（+/-）
+
+	order []string // 清单中的路由顺序
+}
+
+// Order 返回清单中的路由名称顺序（ingress 规则按此顺序匹配）
+func (p *Plan) Order() []string {
+	return p.order
+}
+
+// Empty 返回是否无任何变更
+func (p *Plan) Empty() bool {
+	return p.CreateTunnel == "" && len(p.Routes) == 0 && len(p.Ingress) == 0 && len(p.Frpc) == 0
+}
+
+// Compute 对比当前配置与清单，生成变更计划
+func Compute(cfg *config.Config, m *Manifest) (*Plan, error) {
+	p := &Plan{}
+	if m.Tunnel != "" {
+		switch {
+		case cfg.Tunnel.ID == "":
+			p.CreateTunnel = m.Tunnel
+		case cfg.Tunnel.Name != m.Tunnel:
+			return nil, fmt.Errorf("清单隧道 %s 与当前隧道 %s 不一致，如需重建请先 cftunnel destroy", m.Tunnel, cfg.Tunnel.Name)
+		}
+	} else if cfg.Tunnel.ID == "" {
+		return nil, fmt.Errorf("当前无隧道，请在清单中指定 tunnel 名称")
+	}
+
+	desired := make(map[string]bool)
+	for i := range m.Routes {
+		want := &m.Routes[i]
+		desired[want.Name] = true
+		p.order = append(p.order, want.Name)
+		cur := cfg.FindRoute(want.Name)
+		switch {
+		case cur == nil:
+			p.Routes = append(p.Routes, RouteChange{Action: Create, Name: want.Name, Desired: want})
+		case routeDiffers(cur, want):
+			snapshot := *cur // 应用过程中 cfg.Routes 会变动，保存快照
+			p.Routes = append(p.Routes, RouteChange{Action: Update, Name: want.Name, Current: &snapshot, Desired: want})
+		}
+	}
+	for _, r := range cfg.Routes {
+		if !desired[r.Name] {
+			snapshot := r
+			p.Routes = append(p.Routes, RouteChange{Action: Delete, Name: r.Name, Current: &snapshot})
+		}
+	}
+
+	var before, after []string
+	for _, r := range cfg.Routes {
+		before = append(before, r.Hostname+" → "+r.Service)
+	}
+	for _, r := range m.Routes {
+		after = append(after, r.Hostname+" → "+r.Service)
+	}
+	p.Ingress = diffLines(before, after)
+
+	if m.Relay != nil {
+		frpc, err := frpcDiff(&cfg.Relay, m.Relay)
+		if err != nil {
+			return nil, err
+		}
+		if len(frpc) > 0 {
+			p.Relay = m.Relay
+			p.Frpc = frpc
+		}
+	}
+	return p, nil
+}
+
+func routeDiffers(cur *config.RouteConfig, want *Route) bool {
+	if cur.Hostname != want.Hostname || cur.Service != want.Service || !reflect.DeepEqual(cur.Access, want.Access) ||
+		!reflect.DeepEqual(cur.IPFilter, want.IPFilter) || cur.AccessLog != want.AccessLog {
+		return true
+	}
+	if (cur.Auth == nil) != (want.Auth == nil) {
+		return true
+	}
+	if cur.Auth == nil {
+		return false
+	}
+	return len(authChanges(cur.Auth, want.Auth)) > 0
+}
+
+// authChanges 返回鉴权配置的变更描述，无变更时为空
+func authChanges(cur *config.AuthProxy, want *Auth) []string {
+	var parts []string
+	desired := make(map[string]bool)
+	for _, u := range want.Users {
+		desired[u.Username] = true
+		existing := cur.FindUser(u.Username)
+		switch {
+		case existing == nil:
+			parts = append(parts, "+用户 "+u.Username)
+		case !passwd.Verify(existing.Password, u.Password):
+			parts = append(parts, "用户 "+u.Username+" 密码变更")
+		}
+		if existing != nil && !slices.Equal(existing.Groups, u.Groups) {
+			parts = append(parts, fmt.Sprintf("用户 %s 组 %v → %v", u.Username, existing.Groups, u.Groups))
+		}
+	}
+	for _, u := range cur.Users {
+		if !desired[u.Username] {
+			parts = append(parts, "-用户 "+u.Username)
+		}
+	}
+	if !reflect.DeepEqual(cur.OIDC, want.OIDC) {
+		parts = append(parts, "OIDC 配置变更")
+	}
+	if cur.HtpasswdFile != want.HtpasswdFile {
+		parts = append(parts, fmt.Sprintf("htpasswd 文件 %q → %q", cur.HtpasswdFile, want.HtpasswdFile))
+	}
+	if cur.CookieTTLOrDefault() != want.cookieTTL() {
+		parts = append(parts, "Cookie 有效期变更")
+	}
+	if cur.AnonymousWS != want.AnonymousWS {
+		parts = append(parts, fmt.Sprintf("匿名 WebSocket %v → %v", cur.AnonymousWS, want.AnonymousWS))
+	}
+	if !slices.Equal(cur.PublicPaths, want.PublicPaths) {
+		parts = append(parts, fmt.Sprintf("公开路径 %v → %v", cur.PublicPaths, want.PublicPaths))
+	}
+	if cur.PortalDomain != want.PortalDomain {
+		parts = append(parts, fmt.Sprintf("SSO 门户域名 %q → %q", cur.PortalDomain, want.PortalDomain))
+	}
+	if !reflect.DeepEqual(cur.Headers, want.Headers) {
+		parts = append(parts, "身份头配置变更")
+	}
+	if !reflect.DeepEqual(cur.LoginPage, want.LoginPage) {
+		parts = append(parts, "登录页配置变更")
+	}
+	if cur.SSO != want.SSO {
+		parts = append(parts, fmt.Sprintf("SSO 门户 %q → %q", cur.SSO, want.SSO))
+	}
+	return parts
+}
+
+// frpcDiff 渲染前后的 frpc.toml 并逐行对比，token 脱敏
+func frpcDiff(cur, want *config.RelayConfig) ([]string, error) {
+	render := func(r *config.RelayConfig) ([]string, error) {
+		if r.Server == "" && len(r.Rules) == 0 {
+			return nil, nil
+		}
+		text, err := relay.RenderFrpcConfig(r)
+		if err != nil {
+			return nil, err
+		}
+		if r.Token != "" {
+			text = strings.ReplaceAll(text, fmt.Sprintf("%q", r.Token), `"***`+tokenHint(r.Token)+`"`)
+		}
+		return strings.Split(strings.TrimSpace(text), "\n"), nil
+	}
+	before, err := render(cur)
+	if err != nil {
+		return nil, err
+	}
+	after, err := render(want)
+	if err != nil {
+		return nil, fmt.Errorf("清单 relay 配置无效: %w", err)
+	}
+	return diffLines(before, after), nil
+}
+
+// tokenHint 返回 token 末 4 位，便于区分新旧 token
+func tokenHint(token string) string {
+	if len(token) <= 4 {
+		return ""
+	}
+	return token[len(token)-4:]
+}
+
+// Print 输出人类可读的变更计划
+func (p *Plan) Print(w io.Writer) {
+	if p.Empty() {
+		fmt.Fprintln(w, "无变更，当前配置与清单一致")
+		return
+	}
+	if p.CreateTunnel != "" {
+		fmt.Fprintf(w, "隧道:\n  + 创建隧道 %s\n\n", p.CreateTunnel)
+	}
+
+	var dns, auth []string
+	for _, c := range p.Routes {
+		if c.HostnameChanged() {
+			if c.Current != nil {
+				dns = append(dns, fmt.Sprintf("  - CNAME %s (%s)", c.Current.Hostname, c.Name))
+			}
+			if c.Desired != nil {
+				dns = append(dns, fmt.Sprintf("  + CNAME %s (%s)", c.Desired.Hostname, c.Name))
+			}
+		}
+		if line := authChange(c); line != "" {
+			auth = append(auth, line)
+		}
+		if line := accessChange(c); line != "" {
+			auth = append(auth, line)
+		}
+		if line := ipFilterChange(c); line != "" {
+			auth = append(auth, line)
+		}
+	}
+	printSection(w, "DNS 记录", dns)
+	printSection(w, "Ingress", indent(p.Ingress))
+	printSection(w, "鉴权", auth)
+	printSection(w, "frpc.toml", indent(p.Frpc))
+
+	var create, update, del int
+	for _, c := range p.Routes {
+		switch c.Action {
+		case Create:
+			create++
+		case Update:
+			update++
+		case Delete:
+			del++
+		}
+	}
+	fmt.Fprintf(w, "路由: %d 新增, %d 变更, %d 删除", create, update, del)
+	if p.Relay != nil {
+		fmt.Fprint(w, "；中继配置有变更")
+	}
+	fmt.Fprintln(w)
+}
+
+func authChange(c RouteChange) string {
+	var cur *config.AuthProxy
+	var want *Auth
+	if c.Current != nil {
+		cur = c.Current.Auth
+	}
+	if c.Desired != nil {
+		want = c.Desired.Auth
+	}
+	switch {
+	case cur == nil && want != nil && want.OIDC != nil:
+		return fmt.Sprintf("  + %s: 启用 OIDC 登录 (%s)", c.Name, want.OIDC.Issuer)
+	case cur == nil && want != nil:
+		var names []string
+		for _, u := range want.Users {
+			names = append(names, u.Username)
+		}
+		if want.HtpasswdFile != "" {
+			names = append(names, "htpasswd:"+want.HtpasswdFile)
+		}
+		return fmt.Sprintf("  + %s: 启用密码保护 (用户 %s)", c.Name, strings.Join(names, ", "))
+	case cur != nil && want == nil:
+		return fmt.Sprintf("  - %s: 关闭密码保护", c.Name)
+	case cur != nil && c.Action == Update:
+		if parts := authChanges(cur, want); len(parts) > 0 {
+			return fmt.Sprintf("  ~ %s: %s", c.Name, strings.Join(parts, ", "))
+		}
+	}
+	return ""
+}
+
+func accessChange(c RouteChange) string {
+	var cur, want *config.AccessAuth
+	if c.Current != nil {
+		cur = c.Current.Access
+	}
+	if c.Desired != nil {
+		want = c.Desired.Access
+	}
+	switch {
+	case cur == nil && want != nil:
+		return fmt.Sprintf("  + %s: 启用 Cloudflare Access 校验 (%s)", c.Name, want.TeamDomain)
+	case cur != nil && want == nil:
+		return fmt.Sprintf("  - %s: 关闭 Cloudflare Access 校验", c.Name)
+	case cur != nil && !reflect.DeepEqual(cur, want):
+		return fmt.Sprintf("  ~ %s: Cloudflare Access 配置变更", c.Name)
+	}
+	return ""
+}
+
+func ipFilterChange(c RouteChange) string {
+	var cur, want *config.IPFilter
+	if c.Current != nil {
+		cur = c.Current.IPFilter
+	}
+	if c.Desired != nil {
+		want = c.Desired.IPFilter
+	}
+	switch {
+	case cur == nil && want != nil:
+		return fmt.Sprintf("  + %s: 启用 IP 过滤", c.Name)
+	case cur != nil && want == nil:
+		return fmt.Sprintf("  - %s: 关闭 IP 过滤", c.Name)
+	case cur != nil && !reflect.DeepEqual(cur, want):
+		return fmt.Sprintf("  ~ %s: IP 过滤规则变更", c.Name)
+	}
+	return ""
+}
+
+func printSection(w io.Writer, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+	fmt.Fprintln(w)
+}
+
+func indent(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = "  " + l
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+// Package passwd 鉴权密码的哈希与校验
+package passwd
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hash 生成 bcrypt 哈希
+func Hash(plain string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("生成密码哈希失败: %w", err)
+	}
+	return string(h), nil
+}
+
+// 各哈希格式的完整匹配，仅前缀相同的明文密码（如 "{SHA}abc"）不视为哈希
+var (
+	bcryptRe   = regexp.MustCompile(`^\$2[aby]\$\d{2}\$[./A-Za-z0-9]{53}$`)
+	argon2idRe = regexp.MustCompile(`^\$argon2id\$v=\d+\$m=\d+,t=\d+,p=\d+\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+$`)
+	shaRe      = regexp.MustCompile(`^\{SHA\}[A-Za-z0-9+/]{27}=$`)
+	apr1Re     = regexp.MustCompile(`^\$apr1\$[./0-9A-Za-z]{1,8}\$[./0-9A-Za-z]{22}$`)
+)
+
+// IsHash 判断是否为支持的哈希格式（bcrypt / argon2id / SHA / apr1）
+func IsHash(s string) bool {
+	return isBcrypt(s) || argon2idRe.MatchString(s) || shaRe.MatchString(s) || apr1Re.MatchString(s)
+}
+
+// Verify 以恒定时间校验密码；stored 不是支持的哈希格式（包括明文和空值）时始终失败
+func Verify(stored, plain string) bool {
+	switch {
+	case isBcrypt(stored):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(plain)) == nil
+	case argon2idRe.MatchString(stored):
+		return verifyArgon2id(stored, plain)
+	case shaRe.MatchString(stored):
+		sum := sha1.Sum([]byte(plain))
+		want := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(want)) == 1
+	case apr1Re.MatchString(stored):
+		salt, _, ok := strings.Cut(strings.TrimPrefix(stored, apr1Magic), "$")
+		if !ok {
+			return false
+		}
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(apr1(plain, salt))) == 1
+	}
+	return false
+}
+
+func isBcrypt(s string) bool {
+	return bcryptRe.MatchString(s)
+}
+
+// verifyArgon2id 校验 PHC 格式：$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+func verifyArgon2id(stored, plain string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got := argon2.IDKey([]byte(plain), salt, time, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
@@ -0,0 +1,75 @@
+package passwd
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	bcryptHash, err := Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		stored string
+		plain  string
+		want   bool
+	}{
+		{"bcrypt 正确", bcryptHash, "secret", true},
+		{"bcrypt 错误", bcryptHash, "Secret", false},
+		{"SHA 正确", "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", "password", true},
+		{"SHA 错误", "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", "passwor", false},
+		{"apr1 正确", "$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1", "password", true},
+		{"apr1 错误", "$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1", "password1", false},
+		{"apr1 短盐值含空格密码", "$apr1$xy$bOSEf32PyFOZGDaaxnjqG.", "p@ss word", true},
+		{"apr1 篡改哈希", "$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE2", "password", false},
+		{"明文不再接受", "plain-text", "plain-text", false},
+		{"形似 SHA 的明文", "{SHA}abc", "{SHA}abc", false},
+		{"形似 apr1 的明文", "$apr1$oops", "$apr1$oops", false},
+		{"存储为空、输入为空", "", "", false},
+		{"存储为空、输入非空", "", "x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.stored, tt.plain); got != tt.want {
+				t.Errorf("Verify(%q, %q) = %v, want %v", tt.stored, tt.plain, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHash(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", true},
+		{"$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHQ$aGFzaGhhc2hoYXNo", true},
+		{"{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", true},
+		{"$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1", true},
+		{"{SHA}abc", false},
+		{"$apr1$oops", false},
+		{"$apr1$abcdefghi$FBwExRW4dCc8aL.OvjpIE1", false},
+		{"$2a$10$short", false},
+		{"$argon2id$", false},
+		{"password", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsHash(tt.s); got != tt.want {
+			t.Errorf("IsHash(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestApr1(t *testing.T) {
+	tests := []struct {
+		plain, salt, want string
+	}{
+		{"password", "abcdefgh", "$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1"},
+		{"p@ss word", "xy", "$apr1$xy$bOSEf32PyFOZGDaaxnjqG."},
+	}
+	for _, tt := range tests {
+		if got := apr1(tt.plain, tt.salt); got != tt.want {
+			t.Errorf("apr1(%q, %q) = %s, want %s", tt.plain, tt.salt, got, tt.want)
+		}
+	}
+}
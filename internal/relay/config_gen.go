@@ -22,13 +22,22 @@ func FrpsConfigPath() string {
 
 // GenerateFrpcConfig 从 config.yml 的 relay 配置生成 frpc.toml
 func GenerateFrpcConfig(relay *config.RelayConfig) error {
+	content, err := RenderFrpcConfig(relay)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(FrpcConfigPath(), []byte(content), 0600)
+}
+
+// RenderFrpcConfig 渲染 frpc.toml 内容（不写入文件）
+func RenderFrpcConfig(relay *config.RelayConfig) (string, error) {
 	if relay.Server == "" {
-		return fmt.Errorf("未配置中继服务器，请先执行 cftunnel relay init")
+		return "", fmt.Errorf("未配置中继服务器，请先执行 cftunnel relay init")
 	}
 
 	host, port, err := net.SplitHostPort(relay.Server)
 	if err != nil {
-		return fmt.Errorf("服务器地址格式错误（应为 IP:端口）: %w", err)
+		return "", fmt.Errorf("服务器地址格式错误（应为 IP:端口）: %w", err)
 	}
 
 	var b strings.Builder
@@ -58,7 +67,7 @@ func GenerateFrpcConfig(relay *config.RelayConfig) error {
 		b.WriteString("\n")
 	}
 
-	return os.WriteFile(FrpcConfigPath(), []byte(b.String()), 0600)
+	return b.String(), nil
 }
 
 // GenerateFrpsConfig 生成服务端 frps.toml
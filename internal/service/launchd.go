@@ -3,9 +3,11 @@
 package service
 
 import (
+	"encoding/xml"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"text/template"
 )
 
@@ -23,48 +25,76 @@ const plistTmpl = `<?xml version="1.0" encoding="UTF-8"?>
 <plist version="1.0">
 <dict>
     <key>Label</key>
-    <string>{{.Label}}</string>
+    <string>{{xml .Label}}</string>
     <key>ProgramArguments</key>
     <array>
-        <string>{{.BinPath}}</string>
-        <string>tunnel</string>
-        <string>--protocol</string>
-        <string>http2</string>
-        <string>run</string>
-        <string>--token</string>
-        <string>{{.Token}}</string>
+{{- range .Args}}
+        <string>{{xml .}}</string>
+{{- end}}
     </array>
+{{- if .Env}}
+    <key>EnvironmentVariables</key>
+    <dict>
+{{- range .Env}}
+        <key>{{xml .Key}}</key>
+        <string>{{xml .Value}}</string>
+{{- end}}
+    </dict>
+{{- end}}
     <key>KeepAlive</key>
     <true/>
     <key>RunAtLoad</key>
     <true/>
     <key>StandardOutPath</key>
-    <string>{{.LogPath}}</string>
+    <string>{{xml .LogPath}}</string>
     <key>StandardErrorPath</key>
-    <string>{{.LogPath}}</string>
+    <string>{{xml .LogPath}}</string>
 </dict>
 </plist>
 `
 
-func (l *Launchd) Install(binPath, token string) error {
+// xmlEscape 转义插入 plist 的值，避免参数或环境变量中的 < & 等字符破坏文件结构
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+var plist = template.Must(template.New("").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(plistTmpl))
+
+type plistEnv struct {
+	Key   string
+	Value string
+}
+
+func (l *Launchd) Install(cmd Command) error {
 	home, _ := os.UserHomeDir()
-	data := map[string]string{
+	var env []plistEnv
+	for _, k := range sortedKeys(cmd.Env) {
+		env = append(env, plistEnv{Key: k, Value: cmd.Env[k]})
+	}
+	data := map[string]any{
 		"Label":   plistName,
-		"BinPath": binPath,
-		"Token":   token,
+		"Args":    append([]string{cmd.Path}, cmd.Args...),
+		"Env":     env,
 		"LogPath": filepath.Join(home, "Library/Logs/cftunnel.log"),
 	}
-	f, err := os.Create(l.plistPath())
+	f, err := os.OpenFile(l.plistPath(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	if err := template.Must(template.New("").Parse(plistTmpl)).Execute(f, data); err != nil {
+	f.Chmod(0600) // 覆盖旧版本以 0644 创建的文件
+	if err := plist.Execute(f, data); err != nil {
 		return err
 	}
 	return exec.Command("launchctl", "load", l.plistPath()).Run()
 }
 
+func (l *Launchd) EnvLocation() string {
+	return l.plistPath() + "（权限 0600，仅当前用户可读）"
+}
+
 func (l *Launchd) Uninstall() error {
 	exec.Command("launchctl", "unload", l.plistPath()).Run()
 	return os.Remove(l.plistPath())
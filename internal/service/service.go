@@ -1,8 +1,28 @@
 package service
 
+import "sort"
+
+// Command 由系统服务托管的命令
+type Command struct {
+	Path string            // 可执行文件绝对路径
+	Args []string          // 命令行参数
+	Env  map[string]string // 额外环境变量，会明文写入服务定义（见 EnvLocation），除用户明确要求的口令外不得包含 token 等密钥
+}
+
 // Service 系统服务管理接口
 type Service interface {
-	Install(binPath, token string) error
+	Install(cmd Command) error
+	EnvLocation() string // Command.Env 的保存位置及其访问权限，用于提示用户
 	Uninstall() error
 	Running() bool
 }
+
+// sortedKeys 返回排序后的环境变量名，保证生成的服务文件稳定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 type Systemd struct{}
@@ -16,21 +17,35 @@ func (s *Systemd) unitPath() string {
 	return "/etc/systemd/system/" + unitName + ".service"
 }
 
-func (s *Systemd) Install(binPath, token string) error {
+func (s *Systemd) Install(cmd Command) error {
+	var env strings.Builder
+	for _, k := range sortedKeys(cmd.Env) {
+		fmt.Fprintf(&env, "Environment=%s\n", systemdQuote(k+"="+cmd.Env[k]))
+	}
+	execStart := systemdQuote(cmd.Path)
+	for _, a := range cmd.Args {
+		execStart += " " + systemdQuote(a)
+	}
+
 	unit := fmt.Sprintf(`[Unit]
 Description=Cloudflare Tunnel (cftunnel)
 After=network.target
 
 [Service]
-ExecStart=%s tunnel --protocol http2 run --token %s
+%sExecStart=%s
 Restart=always
 RestartSec=5
+KillMode=mixed
 
 [Install]
 WantedBy=multi-user.target
-`, binPath, token)
+`, env.String(), execStart)
 
-	if err := os.WriteFile(s.unitPath(), []byte(unit), 0644); err != nil {
+	// 环境变量中可能含有配置口令，仅 root 可读
+	if err := os.WriteFile(s.unitPath(), []byte(unit), 0600); err != nil {
+		return err
+	}
+	if err := os.Chmod(s.unitPath(), 0600); err != nil {
 		return err
 	}
 	if err := exec.Command("systemctl", "daemon-reload").Run(); err != nil {
@@ -39,6 +54,22 @@ WantedBy=multi-user.target
 	return exec.Command("systemctl", "enable", "--now", unitName).Run()
 }
 
+// systemdQuote 为含空格或特殊字符的参数加引号
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\$%;") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "$", "$$")
+	s = strings.ReplaceAll(s, "%", "%%")
+	return `"` + s + `"`
+}
+
+func (s *Systemd) EnvLocation() string {
+	return s.unitPath() + "（权限 0600，仅 root 可读）"
+}
+
 func (s *Systemd) Uninstall() error {
 	exec.Command("systemctl", "disable", "--now", unitName).Run()
 	return os.Remove(s.unitPath())
@@ -6,20 +6,65 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
 )
 
 type Windows struct{}
 
 const svcName = "cftunnel"
 
-func (w *Windows) Install(binPath, token string) error {
-	binArg := fmt.Sprintf(`%s tunnel --protocol http2 run --token %s`, binPath, token)
+const svcKey = `HKLM\SYSTEM\CurrentControlSet\Services\` + svcName
+
+// svcKeySDDL 服务注册表键仅 SYSTEM 和管理员可访问（默认所有本地用户可读），
+// 避免普通用户读取 Environment 中的口令
+const svcKeySDDL = "D:P(A;CI;KA;;;SY)(A;CI;KA;;;BA)"
+
+func (w *Windows) Install(cmd Command) error {
+	binArg := syscall.EscapeArg(cmd.Path)
+	for _, a := range cmd.Args {
+		binArg += " " + syscall.EscapeArg(a)
+	}
 	if err := exec.Command("sc", "create", svcName, "binPath=", binArg, "start=", "auto").Run(); err != nil {
 		return fmt.Errorf("创建服务失败: %w", err)
 	}
+	// 服务级环境变量写入注册表 Environment（REG_MULTI_SZ），写入前先收紧键的访问权限
+	if len(cmd.Env) > 0 {
+		if err := restrictServiceKey(); err != nil {
+			exec.Command("sc", "delete", svcName).Run()
+			return fmt.Errorf("限制服务注册表权限失败: %w", err)
+		}
+		var vars []string
+		for _, k := range sortedKeys(cmd.Env) {
+			vars = append(vars, k+"="+cmd.Env[k])
+		}
+		if err := exec.Command("reg", "add", svcKey, "/v", "Environment", "/t", "REG_MULTI_SZ",
+			"/d", strings.Join(vars, `\0`), "/f").Run(); err != nil {
+			return fmt.Errorf("写入服务环境变量失败: %w", err)
+		}
+	}
 	return exec.Command("sc", "start", svcName).Run()
 }
 
+// restrictServiceKey 以受保护的 DACL 替换服务注册表键的权限，不再继承 Services 键的 Users 读权限
+func restrictServiceKey() error {
+	sd, err := windows.SecurityDescriptorFromString(svcKeySDDL)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(`MACHINE\SYSTEM\CurrentControlSet\Services\`+svcName, windows.SE_REGISTRY_KEY,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, dacl, nil)
+}
+
+func (w *Windows) EnvLocation() string {
+	return "注册表 " + svcKey + " 的 Environment 值（已限制仅 SYSTEM 和管理员可访问）"
+}
+
 func (w *Windows) Uninstall() error {
 	exec.Command("sc", "stop", svcName).Run()
 	return exec.Command("sc", "delete", svcName).Run()
@@ -0,0 +1,328 @@
+// Package session 鉴权代理的服务端会话存储：登录时登记，登出或吊销后立即失效；同时记录分享链接的兑换次数
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qingchencloud/cftunnel/internal/config"
+)
+
+// Session 已登录会话
+type Session struct {
+	ID          string    `json:"id"`
+	User        string    `json:"user"`
+	Created     time.Time `json:"created"`
+	Expires     time.Time `json:"expires"`
+	IP          string    `json:"ip,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	PasswordTag string    `json:"password_tag,omitempty"` // 登录时密码哈希的指纹，密码修改后会话失效
+	Share       string    `json:"share,omitempty"`        // 通过分享链接兑换时为链接 ID
+	Groups      []string  `json:"groups,omitempty"`       // OIDC 登录时 ID Token 中的 groups
+}
+
+// ErrShareUsedUp 分享链接已达到使用次数上限
+var ErrShareUsedUp = errors.New("分享链接已达到使用次数上限")
+
+// ErrShareRevoked 分享链接已被吊销
+var ErrShareRevoked = errors.New("分享链接已被吊销")
+
+// shareUse 分享链接的登记信息和兑换次数，链接过期后清理
+type shareUse struct {
+	Count   int       `json:"count"`
+	Expires time.Time `json:"expires"`
+	Revoked bool      `json:"revoked,omitempty"`
+}
+
+// state 会话文件内容
+type state struct {
+	Sessions map[string]*Session
+	Shares   map[string]*shareUse
+}
+
+// fileFormat 会话文件的 JSON 格式
+type fileFormat struct {
+	Sessions []*Session           `json:"sessions"`
+	Shares   map[string]*shareUse `json:"shares,omitempty"`
+}
+
+func newState() *state {
+	return &state{Sessions: make(map[string]*Session), Shares: make(map[string]*shareUse)}
+}
+
+// Store 路由的会话存储，持久化到 <配置目录>/sessions/[<上下文>/]<路由>.json
+// 代理进程登记和删除会话，CLI 吊销会话；文件变化时自动重新加载，写入时加跨进程锁
+type Store struct {
+	path string // 为空时仅保存在内存中（quick 模式）
+
+	mu    sync.Mutex
+	mod   time.Time
+	size  int64
+	state *state
+}
+
+// Path 返回路由会话文件路径，不同上下文的同名路由互不共享；default 上下文沿用旧路径
+func Path(profile, route string) string {
+	if profile == "" || profile == config.DefaultProfile {
+		return filepath.Join(config.Dir(), "sessions", route+".json")
+	}
+	return filepath.Join(config.Dir(), "sessions", profile, route+".json")
+}
+
+// Open 打开上下文中路由的会话存储
+func Open(profile, route string) *Store {
+	return &Store{path: Path(profile, route)}
+}
+
+// Memory 创建仅保存在内存中的会话存储
+func Memory() *Store {
+	return &Store{state: newState()}
+}
+
+// NewID 生成随机会话 ID
+func NewID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Get 查找未过期的会话
+func (s *Store) Get(id string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	if s.state == nil {
+		return nil, false
+	}
+	sess, ok := s.state.Sessions[id]
+	if !ok || time.Now().After(sess.Expires) {
+		return nil, false
+	}
+	return sess, true
+}
+
+// List 返回全部未过期的会话，按创建时间排序
+func (s *Store) List() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	if s.state == nil {
+		return nil
+	}
+	now := time.Now()
+	var out []Session
+	for _, sess := range s.state.Sessions {
+		if now.Before(sess.Expires) {
+			out = append(out, *sess)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out
+}
+
+// Add 登记会话
+func (s *Store) Add(sess Session) error {
+	return s.update(func(st *state) int {
+		st.Sessions[sess.ID] = &sess
+		return 1
+	})
+}
+
+// IssueShare 登记新生成的分享链接，吊销时据此得知链接的有效期
+func (s *Store) IssueShare(id string, expires time.Time) error {
+	return s.update(func(st *state) int {
+		if st.Shares[id] != nil {
+			return 0
+		}
+		st.Shares[id] = &shareUse{Expires: expires}
+		return 1
+	})
+}
+
+// Redeem 兑换分享链接：未吊销且未超过使用次数上限（max 为 0 表示不限）时计数并登记会话，
+// 否则返回 ErrShareRevoked 或 ErrShareUsedUp
+func (s *Store) Redeem(sess Session, max int) error {
+	var refused error
+	err := s.update(func(st *state) int {
+		use := st.Shares[sess.Share]
+		if use == nil {
+			use = &shareUse{Expires: sess.Expires}
+			st.Shares[sess.Share] = use
+		}
+		switch {
+		case use.Revoked:
+			refused = ErrShareRevoked
+			return 0
+		case max > 0 && use.Count >= max:
+			refused = ErrShareUsedUp
+			return 0
+		}
+		use.Count++
+		st.Sessions[sess.ID] = &sess
+		return 1
+	})
+	if err == nil {
+		err = refused
+	}
+	return err
+}
+
+// RevokeShare 吊销分享链接并删除已兑换的会话，返回删除的会话数；链接未登记或已过期时 found 为 false
+func (s *Store) RevokeShare(id string) (removed int, found bool, err error) {
+	err = s.update(func(st *state) int {
+		use := st.Shares[id]
+		if use == nil || time.Now().After(use.Expires) {
+			return 0
+		}
+		found = true
+		use.Revoked = true
+		for sid, sess := range st.Sessions {
+			if sess.Share == id {
+				delete(st.Sessions, sid)
+				removed++
+			}
+		}
+		return 1
+	})
+	return removed, found, err
+}
+
+// Remove 删除匹配的会话，返回删除数量
+func (s *Store) Remove(match func(Session) bool) (int, error) {
+	var n int
+	err := s.update(func(st *state) int {
+		for id, sess := range st.Sessions {
+			if match(*sess) {
+				delete(st.Sessions, id)
+				n++
+			}
+		}
+		return n
+	})
+	return n, err
+}
+
+// update 加锁读取最新内容，修改后清理过期记录并写回；fn 返回 0 且无过期记录时不写文件
+func (s *Store) update(fn func(*state) int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" {
+		fn(s.state)
+		prune(s.state)
+		return nil
+	}
+
+	unlock, err := config.LockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	st, err := s.read()
+	if err != nil {
+		return err
+	}
+	changed := fn(st) + prune(st)
+	s.state = st
+	if changed == 0 {
+		return nil
+	}
+	return s.write(st)
+}
+
+// prune 清理过期的会话和分享链接计数，返回清理数量
+func prune(st *state) int {
+	n := 0
+	now := time.Now()
+	for id, sess := range st.Sessions {
+		if now.After(sess.Expires) {
+			delete(st.Sessions, id)
+			n++
+		}
+	}
+	for id, use := range st.Shares {
+		if now.After(use.Expires) {
+			delete(st.Shares, id)
+			n++
+		}
+	}
+	return n
+}
+
+// refresh 文件变化时重新加载（调用方持有 s.mu）
+func (s *Store) refresh() {
+	if s.path == "" {
+		return
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.state, s.mod, s.size = nil, time.Time{}, 0
+		}
+		return
+	}
+	if s.state != nil && info.ModTime().Equal(s.mod) && info.Size() == s.size {
+		return
+	}
+	if st, err := s.read(); err == nil {
+		s.state = st
+	}
+}
+
+// read 读取会话文件并记录文件状态（调用方持有 s.mu）
+func (s *Store) read() (*state, error) {
+	st := newState()
+	// 先记录文件状态再读取，读取期间的修改会在下次 refresh 时发现
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for _, sess := range f.Sessions {
+		st.Sessions[sess.ID] = sess
+	}
+	for id, use := range f.Shares {
+		st.Shares[id] = use
+	}
+	s.mod, s.size = info.ModTime(), info.Size()
+	return st, nil
+}
+
+func (s *Store) write(st *state) error {
+	f := fileFormat{Sessions: make([]*Session, 0, len(st.Sessions)), Shares: st.Shares}
+	for _, sess := range st.Sessions {
+		f.Sessions = append(f.Sessions, sess)
+	}
+	sort.Slice(f.Sessions, func(i, j int) bool { return f.Sessions[i].Created.Before(f.Sessions[j].Created) })
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := config.WriteFileAtomic(s.path, data, 0600); err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.mod, s.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
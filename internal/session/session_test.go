@@ -0,0 +1,165 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newFileStore(t *testing.T) *Store {
+	t.Helper()
+	return &Store{path: filepath.Join(t.TempDir(), "route.json")}
+}
+
+func TestRedeem(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		redeems int
+		wantOK  int
+	}{
+		{"单次使用", 1, 3, 1},
+		{"最多三次", 3, 5, 3},
+		{"不限次数", 0, 5, 5},
+	}
+	for _, tt := range tests {
+		for _, kind := range []string{"memory", "file"} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				s := Memory()
+				if kind == "file" {
+					s = newFileStore(t)
+				}
+				ok := 0
+				for i := 0; i < tt.redeems; i++ {
+					sess := Session{ID: NewID(), User: "share:abc", Share: "abc", Expires: time.Now().Add(time.Hour)}
+					err := s.Redeem(sess, tt.max)
+					switch {
+					case err == nil:
+						ok++
+					case !errors.Is(err, ErrShareUsedUp):
+						t.Fatalf("Redeem: %v", err)
+					}
+				}
+				if ok != tt.wantOK {
+					t.Errorf("成功兑换 %d 次, want %d", ok, tt.wantOK)
+				}
+				if n := len(s.List()); n != tt.wantOK {
+					t.Errorf("会话数 %d, want %d", n, tt.wantOK)
+				}
+			})
+		}
+	}
+}
+
+// 兑换次数按文件持久化，另一个进程（新的 Store）继续计数
+func TestRedeemAcrossStores(t *testing.T) {
+	a := newFileStore(t)
+	b := &Store{path: a.path}
+	sess := func() Session {
+		return Session{ID: NewID(), Share: "abc", Expires: time.Now().Add(time.Hour)}
+	}
+	if err := a.Redeem(sess(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Redeem(sess(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Redeem(sess(), 2); !errors.Is(err, ErrShareUsedUp) {
+		t.Errorf("第三次兑换 err = %v, want ErrShareUsedUp", err)
+	}
+}
+
+// 吊销由另一个进程（CLI）写入文件，运行中的 Store 拒绝兑换并丢弃已兑换的会话
+func TestRevokeShare(t *testing.T) {
+	a := newFileStore(t)
+	cli := &Store{path: a.path}
+	sess := func(share string) Session {
+		return Session{ID: NewID(), Share: share, Expires: time.Now().Add(time.Hour)}
+	}
+	if err := cli.IssueShare("abc", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	first := sess("abc")
+	if err := a.Redeem(first, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Redeem(sess("other"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, found, err := cli.RevokeShare("abc")
+	if err != nil || !found || removed != 1 {
+		t.Fatalf("RevokeShare = %d, %v, %v, want 1, true, nil", removed, found, err)
+	}
+	if _, ok := a.Get(first.ID); ok {
+		t.Error("吊销后已兑换的会话仍然有效")
+	}
+	if err := a.Redeem(sess("abc"), 0); !errors.Is(err, ErrShareRevoked) {
+		t.Errorf("吊销后兑换 err = %v, want ErrShareRevoked", err)
+	}
+	if n := len(a.List()); n != 1 {
+		t.Errorf("会话数 %d, want 1（其他链接不受影响）", n)
+	}
+	if _, found, _ := cli.RevokeShare("missing"); found {
+		t.Error("未登记的链接 found = true")
+	}
+}
+
+// 其他进程修改或删除会话文件后，Get 重新加载
+func TestStaleFileRefresh(t *testing.T) {
+	proxy := newFileStore(t)
+	cli := &Store{path: proxy.path}
+
+	alice := Session{ID: "s1", User: "alice", Expires: time.Now().Add(time.Hour)}
+	bob := Session{ID: "s2", User: "bob", Expires: time.Now().Add(time.Hour)}
+	for _, sess := range []Session{alice, bob} {
+		if err := proxy.Add(sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := cli.Get("s1"); !ok {
+		t.Fatal("另一个 Store 看不到已登记的会话")
+	}
+
+	n, err := cli.Remove(func(s Session) bool { return s.User == "alice" })
+	if err != nil || n != 1 {
+		t.Fatalf("Remove = (%d, %v)", n, err)
+	}
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"s1", false},
+		{"s2", true},
+	}
+	for _, tt := range tests {
+		if _, ok := proxy.Get(tt.id); ok != tt.want {
+			t.Errorf("吊销后 Get(%s) = %v, want %v", tt.id, ok, tt.want)
+		}
+	}
+
+	if err := os.Remove(proxy.path); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := proxy.Get("s2"); ok {
+		t.Error("会话文件删除后仍能读取会话")
+	}
+}
+
+func TestExpiredSessions(t *testing.T) {
+	s := newFileStore(t)
+	if err := s.Add(Session{ID: "old", Expires: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Session{ID: "new", Expires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get("old"); ok {
+		t.Error("过期会话仍然有效")
+	}
+	if list := s.List(); len(list) != 1 || list[0].ID != "new" {
+		t.Errorf("List = %v, want [new]", list)
+	}
+}
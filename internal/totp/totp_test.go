@@ -0,0 +1,81 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量（密钥 "12345678901234567890"），取末 6 位
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Counter(now)
+	code := func(counter int64) string {
+		c, err := Code(rfcSecret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		code    string
+		wantOK  bool
+		wantCtr int64
+	}{
+		{"当前步", rfcSecret, code(step), true, step},
+		{"前一步（时钟偏差内）", rfcSecret, code(step - 1), true, step - 1},
+		{"后一步（时钟偏差内）", rfcSecret, code(step + 1), true, step + 1},
+		{"超出偏差（前两步）", rfcSecret, code(step - 2), false, 0},
+		{"超出偏差（后两步）", rfcSecret, code(step + 2), false, 0},
+		{"两端空白", rfcSecret, " " + code(step) + "\n", true, step},
+		{"小写且含空格的密钥", "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", code(step), true, step},
+		{"位数不足", rfcSecret, code(step)[:5], false, 0},
+		{"空验证码", rfcSecret, "", false, 0},
+		{"密钥无效", "not-base32!", code(step), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctr, ok := Verify(tt.secret, tt.code, now)
+			if ok != tt.wantOK || ctr != tt.wantCtr {
+				t.Errorf("Verify = (%d, %v), want (%d, %v)", ctr, ok, tt.wantCtr, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	s := GenerateSecret()
+	if _, err := Code(s, 1); err != nil {
+		t.Fatalf("生成的密钥无法使用: %v", err)
+	}
+	if s == GenerateSecret() {
+		t.Error("两次生成的密钥相同")
+	}
+}